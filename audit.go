@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+
+	"github.com/Ax6/risiko/pkg/fairness"
+	"github.com/Ax6/risiko/pkg/risiko"
+)
+
+// Tests the fairness of a dices source or of a log of physical rolls, failing
+// when any test fails
+func runAudit(ctx context.Context, args []string) error {
+	flags := newFlagSet("audit", "Runs goodness of fit and serial correlation tests on a dices source or on a\nlog of physical rolls, and fails when any test fails.")
+	nRolls := flags.Int("rolls", 100000, "dices to roll from the source")
+	source := flags.String("source", "global", "source to audit, any of global, pcg, chacha8 or crypto")
+	seed := flags.Uint64("seed", 0, "seed of the pcg and chacha8 sources, 0 picks a random one")
+	logFile := flags.String("log", "", "audit the whitespace separated rolls of this file instead of a source, - reads stdin")
+	alpha := flags.Float64("alpha", 0.01, "significance level of the tests")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	// The tests need at least two rolls
+	if *logFile == "" && *nRolls < 2 {
+		return usageErrorf("-rolls must be at least 2, got %d", *nRolls)
+	}
+	if *alpha <= 0 || *alpha >= 1 {
+		return usageErrorf("-alpha must be between 0 and 1, got %f", *alpha)
+	}
+
+	var rolls []int
+	var err error
+	if *logFile != "" {
+		rolls, err = readRollsFile(*logFile)
+	} else {
+		var gen risiko.DicesGenerator
+		gen, err = auditedDicesGen(*source, *seed)
+		if err == nil {
+			rolls, err = fairness.Sample(gen, *nRolls)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	report, err := fairness.Audit(rolls, nil)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d rolls, faces %v\n", report.NRolls, report.Counts)
+	for _, result := range report.Results() {
+		verdict := "pass"
+		if !result.Passed(*alpha) {
+			verdict = "FAIL"
+		}
+		fmt.Printf("%-20s statistic %-12.6g p-value %-12.6g %s\n", result.Name, result.Statistic, result.PValue, verdict)
+	}
+	if !report.Passed(*alpha) {
+		return errFailed
+	}
+	return nil
+}
+
+func auditedDicesGen(source string, seed uint64) (risiko.DicesGenerator, error) {
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	switch source {
+	case "global":
+		return risiko.FairDicesGen, nil
+	case "pcg":
+		return risiko.NewSeededDicesGen(seed, 0), nil
+	case "chacha8":
+		var key [32]byte
+		for i := range 4 {
+			for j := range 8 {
+				key[i*8+j] = byte(seed >> (8 * j))
+			}
+		}
+		return risiko.NewDicesGen(rand.NewChaCha8(key)), nil
+	case "crypto":
+		return risiko.NewDicesGen(risiko.CryptoSource{}), nil
+	default:
+		return nil, usageErrorf("unknown source %q, want one of global, pcg, chacha8 or crypto", source)
+	}
+}
+
+func readRollsFile(name string) ([]int, error) {
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+	return fairness.ReadRolls(r)
+}
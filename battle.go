@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"os"
+
+	"github.com/Ax6/risiko/pkg/risiko"
+)
+
+// Simulates a single battle, printing every round
+func runBattle(ctx context.Context, args []string) error {
+	flags := newFlagSet("battle", "Simulates a single battle and prints every round. The same seed plays the\nsame battle again.")
+	nAttackers := flags.Int("attackers", 10, "attacking units")
+	nDefenders := flags.Int("defenders", 5, "defending units")
+	rulesName := flags.String("rules", "risiko", fmt.Sprintf("rules to play, any of %v", risiko.RulesPresetNames()))
+	attackerSpec := flags.String("attacker", "max", attackerStrategyHelp)
+	defenderSpec := flags.String("defender", "max", defenderStrategyHelp)
+	seed := flags.Uint64("seed", 0, "seed of the dices, 0 picks a random one")
+	transcriptFile := flags.String("transcript", "", "also write the rounds to this file as JSON Lines")
+	quiet := flags.Bool("quiet", false, "only print the final state")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	rules, err := risiko.RulesPreset(*rulesName)
+	if err != nil {
+		return &usageError{err: err}
+	}
+	attacker, err := parseStrategy(risiko.AttackerSide, *attackerSpec)
+	if err != nil {
+		return err
+	}
+	defender, err := parseStrategy(risiko.DefenderSide, *defenderSpec)
+	if err != nil {
+		return err
+	}
+	state := risiko.BattleState{AttackerUnits: *nAttackers, DefenderUnits: *nDefenders}
+	if state.AttackerUnits < 0 || state.DefenderUnits < 0 {
+		return usageErrorf("cannot battle with negative units %v", state)
+	}
+	if *seed == 0 {
+		*seed = rand.Uint64()
+	}
+
+	transcript := &risiko.Transcript{}
+	recorders := multiRecorder{transcript}
+	if !*quiet {
+		fmt.Printf("%d attackers against %d defenders with seed %d\n", state.AttackerUnits, state.DefenderUnits, *seed)
+		recorders = append(recorders, &roundPrinter{w: os.Stdout})
+	}
+	final, err := risiko.BattleRecorded(rules, state,
+		attacker(risiko.NewSeededDicesGen(*seed, 0)),
+		defender(risiko.NewSeededDicesGen(*seed, 1)),
+		recorders)
+	if err != nil {
+		return err
+	}
+	printFinalState(final)
+
+	if *transcriptFile != "" {
+		file, err := os.Create(*transcriptFile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if err := transcript.WriteJSONLines(file); err != nil {
+			return err
+		}
+		return file.Close()
+	}
+	return nil
+}
+
+func printFinalState(final risiko.BattleState) {
+	if final.AttackerWon() {
+		fmt.Printf("Attacker wins with %d units left\n", final.AttackerUnits)
+	} else {
+		fmt.Printf("Defender holds with %d units left, %d attackers left\n", final.DefenderUnits, final.AttackerUnits)
+	}
+}
+
+// Passes every round and the ending to all the recorders
+type multiRecorder []risiko.Recorder
+
+func (m multiRecorder) RecordRound(round risiko.Round) {
+	for _, recorder := range m {
+		recorder.RecordRound(round)
+	}
+}
+
+func (m multiRecorder) RecordEnding(ending risiko.Ending) {
+	for _, recorder := range m {
+		if recorder, ok := recorder.(risiko.EndRecorder); ok {
+			recorder.RecordEnding(ending)
+		}
+	}
+}
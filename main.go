@@ -2,120 +2,138 @@ package main
 
 import (
 	"context"
-	"encoding/csv"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
-	"strconv"
+	"os/signal"
+	"strings"
+)
 
-	"github.com/Ax6/risiko/pkg/risiko"
+// Exit codes
+const (
+	exitOK = 0
+	// The command failed or, like audit, ran fine but found a problem
+	exitError = 1
+	// The command line is wrong
+	exitUsage = 2
 )
 
-func saveCSV(filename string, header []string, data [][]string) error {
-	// Create or open the CSV file
-	file, err := os.Create(filename)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
+// Error in the command line rather than in running the command
+type usageError struct {
+	err error
+}
 
-	// Create CSV writer
-	writer := csv.NewWriter(file)
+func usageErrorf(format string, args ...any) error {
+	return &usageError{err: fmt.Errorf(format, args...)}
+}
 
-	// Write the header
-	if err := writer.Write(header); err != nil {
-		return err
-	}
+func (e *usageError) Error() string {
+	return e.err.Error()
+}
 
-	// Write data
-	for _, record := range data {
-		if err := writer.Write(record); err != nil {
-			return err
-		}
-	}
+func (e *usageError) Unwrap() error {
+	return e.err
+}
 
-	// Flush the writer
-	writer.Flush()
+// Returned by commands that already explained why they failed
+var errFailed = errors.New("failed")
 
-	// Check for errors while writing
-	if err := writer.Error(); err != nil {
-		return err
-	}
-	return nil
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, args []string) error
 }
 
-func generateCSVTables(simResult risiko.SimulationSweep, unitsSweep int) {
-	// Initialize slices to store the data for the two tables
-	var victoryTable [][]string
-	var attackersLeftTable [][]string
-	var expectedAttackersLeftTable [][]string
-
-	// Create headers for the tables
-	header := []string{"nUnits"}
-	for i := 1; i <= unitsSweep; i++ {
-		header = append(header, strconv.Itoa(i)) // Add attacker units to header
+func commands() []command {
+	return []command{
+		{name: "sweep", summary: "write the tables of every battle up to some units", run: runSweep},
+		{name: "battle", summary: "simulate a single battle round by round", run: runBattle},
+		{name: "odds", summary: "solve the exact odds of a battle", run: runOdds},
+		{name: "plot", summary: "draw the heatmaps of a sweep saved as JSON", run: runPlot},
+		{name: "report", summary: "write an HTML report of one or two sweeps saved as JSON", run: runReport},
+		{name: "play", summary: "track a battle rolled with real dices", run: runPlay},
+		{name: "audit", summary: "test the fairness of dices", run: runAudit},
 	}
+}
 
-	// Iterate over the simulation results to calculate percentages
-	for nDefenders := 1; nDefenders <= unitsSweep; nDefenders++ {
-		victoryRow := []string{strconv.Itoa(nDefenders)}               // First column: nDefenderUnits
-		attackersLeftRow := []string{strconv.Itoa(nDefenders)}         // First column: nDefenderUnits
-		expectedAttackersLeftRow := []string{strconv.Itoa(nDefenders)} // First column: nDefenderUnits
-
-		// Iterate over attacker units (columns)
-		for nAttackers := risiko.ENGAGE_RULE_MIN_ATTACK; nAttackers <= unitsSweep; nAttackers++ {
-			result := simResult[nAttackers][nDefenders]
-
-			// Calculate the victory percentage for the attacker
-			victoryPercentage := float64(result.NAttackerWon) / float64(result.NRuns)
-			victoryRow = append(victoryRow, fmt.Sprintf("%.6f", victoryPercentage))
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: risiko <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands() {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun 'risiko <command> -help' for the flags of a command. Without a command\nrisiko runs sweep.\n")
+}
 
-			// Calculate units left when won
-			attackersLeftCount := float64(result.TotalAttackerUnitsLeft-(result.NRuns-result.NAttackerWon)) / float64(result.NAttackerWon)
-			attackersLeftRow = append(attackersLeftRow, fmt.Sprintf("%.6f", attackersLeftCount))
+// Flag set of a command printing its usage on -help
+func newFlagSet(name string, description string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: risiko %s [flags]\n\n%s\n\nFlags:\n", name, description)
+		flags.PrintDefaults()
+	}
+	return flags
+}
 
-			// Calculate the percentage of expected attackers left
-			expectedAttackersLeftPercentage := float64(result.TotalAttackerUnitsLeft) / float64(nAttackers*result.NRuns)
-			expectedAttackersLeftRow = append(expectedAttackersLeftRow, fmt.Sprintf("%.6f", expectedAttackersLeftPercentage))
+// Parses the flags of a command, which don't take any other argument
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
 		}
-
-		// Add the rows to the tables
-		victoryTable = append(victoryTable, victoryRow)
-		attackersLeftTable = append(attackersLeftTable, attackersLeftRow)
-		expectedAttackersLeftTable = append(expectedAttackersLeftTable, expectedAttackersLeftRow)
+		// The flag set already printed the error and its usage
+		return &usageError{err: errFailed}
 	}
-
-	if err := saveCSV("victory_percentage.csv", header, victoryTable); err != nil {
-		log.Fatalf("Error saving victory table: %v", err)
+	if flags.NArg() > 0 {
+		return usageErrorf("unexpected arguments %v", flags.Args())
 	}
-	if err := saveCSV("attackers_left.csv", header, attackersLeftTable); err != nil {
-		log.Fatalf("Error saving attackers left table: %v", err)
+	return nil
+}
+
+func run(args []string) int {
+	// Context for simulation, interrupted with Ctrl+C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	name := "sweep"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	} else if len(args) > 0 && (args[0] == "-h" || args[0] == "-help" || args[0] == "--help") {
+		usage()
+		return exitOK
 	}
-	if err := saveCSV("expected_attackers_left_percentage.csv", header, expectedAttackersLeftTable); err != nil {
-		log.Fatalf("Error saving attackers left table: %v", err)
+	if name == "help" {
+		usage()
+		return exitOK
 	}
 
-	log.Println("CSV files created successfully.")
+	for _, cmd := range commands() {
+		if cmd.name != name {
+			continue
+		}
+		err := cmd.run(ctx, args)
+		var usageErr *usageError
+		switch {
+		case err == nil || errors.Is(err, flag.ErrHelp):
+			return exitOK
+		case errors.As(err, &usageErr):
+			if !errors.Is(err, errFailed) {
+				fmt.Fprintf(os.Stderr, "risiko %s: %v\nRun 'risiko %s -help' for usage.\n", name, err, name)
+			}
+			return exitUsage
+		case errors.Is(err, errFailed):
+			return exitError
+		default:
+			log.Printf("Error in %s: %v", name, err)
+			return exitError
+		}
+	}
+	fmt.Fprintf(os.Stderr, "risiko: unknown command %q\n\n", name)
+	usage()
+	return exitUsage
 }
 
 func main() {
-	// Context for simulation
-	ctx := context.Background()
-
-	// Sample parameters
-	nRuns := 10000
-	unitsSweep := 20
-	attacker := risiko.NewMaxAttackersStrategy(risiko.FairDicesGen)
-	defender := risiko.NewMaxDefendersStrategy(risiko.FairDicesGen)
-	log.Println("Starting simulation....")
-
-	// Simulate and get the results
-	simResult, err := risiko.Simulate(ctx, nRuns, unitsSweep, attacker, defender)
-	if err != nil {
-		log.Fatalf("Error in simulation: %v", err)
-	}
-	log.Println("Simulation finished successfully!")
-
-	// Generate and save the CSV tables
-	generateCSVTables(simResult, unitsSweep)
+	os.Exit(run(os.Args[1:]))
 }
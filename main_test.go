@@ -0,0 +1,224 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Ax6/risiko/pkg/risiko"
+)
+
+func isUsageError(err error) bool {
+	var usageErr *usageError
+	return errors.As(err, &usageErr)
+}
+
+func TestRunExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	loadedLog := filepath.Join(dir, "loaded.txt")
+	if err := os.WriteFile(loadedLog, []byte(strings.Repeat("6 ", 600)), 0o644); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	testCases := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{name: "help", args: []string{"help"}, want: exitOK},
+		{name: "help flag", args: []string{"-h"}, want: exitOK},
+		{name: "command help", args: []string{"odds", "-help"}, want: exitOK},
+		{name: "odds", args: []string{"odds", "-attackers", "5", "-defenders", "3"}, want: exitOK},
+		{name: "sweep", args: []string{"sweep", "-units", "3", "-runs", "10", "-seed", "1", "-out", dir}, want: exitOK},
+		{name: "sweep without command", args: []string{"-units", "3", "-exact", "-format", "json", "-out", dir}, want: exitOK},
+		{name: "unknown command", args: []string{"conquer"}, want: exitUsage},
+		{name: "unknown flag", args: []string{"odds", "-bogus"}, want: exitUsage},
+		{name: "unexpected argument", args: []string{"odds", "extra"}, want: exitUsage},
+		{name: "unknown format", args: []string{"sweep", "-format", "xml"}, want: exitUsage},
+		{name: "impossible odds", args: []string{"battle", "-attacker", "retreat:odds=1.5"}, want: exitUsage},
+		{name: "unknown rules", args: []string{"battle", "-rules", "monopoly"}, want: exitUsage},
+		{name: "unknown play strategy", args: []string{"play", "-defender", "retreat:units=3"}, want: exitUsage},
+		{name: "negative rolls", args: []string{"audit", "-rolls", "-1"}, want: exitUsage},
+		{name: "single roll", args: []string{"audit", "-rolls", "1"}, want: exitUsage},
+		{name: "missing file", args: []string{"plot", "-in", filepath.Join(dir, "missing.json")}, want: exitError},
+		{name: "failed audit", args: []string{"audit", "-log", loadedLog}, want: exitError},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := run(tc.args); got != tc.want {
+				t.Errorf("Expected exit code %d but got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestParseStrategy(t *testing.T) {
+	testCases := []struct {
+		side    risiko.Side
+		spec    string
+		wantErr bool
+	}{
+		{side: risiko.AttackerSide, spec: "max"},
+		{side: risiko.DefenderSide, spec: "max"},
+		{side: risiko.AttackerSide, spec: "cap:2"},
+		{side: risiko.DefenderSide, spec: "cap:1"},
+		{side: risiko.AttackerSide, spec: "retreat:units=3,ratio=0.5,odds=0.2"},
+		{side: risiko.AttackerSide, spec: "max:3", wantErr: true},
+		{side: risiko.AttackerSide, spec: "cap:0", wantErr: true},
+		{side: risiko.AttackerSide, spec: "cap:two", wantErr: true},
+		{side: risiko.DefenderSide, spec: "retreat:units=3", wantErr: true},
+		{side: risiko.AttackerSide, spec: "retreat", wantErr: true},
+		{side: risiko.AttackerSide, spec: "charge", wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.side.String()+" "+tc.spec, func(t *testing.T) {
+			factory, err := parseStrategy(tc.side, tc.spec)
+			if tc.wantErr {
+				if !isUsageError(err) {
+					t.Errorf("Expected a usage error but got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			attacker := risiko.NewMaxAttackersStrategy(risiko.NewSeededDicesGen(1, 0))
+			defender := risiko.NewMaxDefendersStrategy(risiko.NewSeededDicesGen(1, 1))
+			if tc.side == risiko.AttackerSide {
+				attacker = factory(risiko.NewSeededDicesGen(1, 0))
+			} else {
+				defender = factory(risiko.NewSeededDicesGen(1, 1))
+			}
+			if _, err := risiko.Battle(risiko.RisiKoRules, risiko.BattleState{AttackerUnits: 10, DefenderUnits: 5}, attacker, defender); err != nil {
+				t.Errorf("Unexpected error %v", err)
+			}
+		})
+	}
+}
+
+func TestParseRetreatThresholds(t *testing.T) {
+	testCases := []struct {
+		params  string
+		want    risiko.RetreatThresholds
+		wantErr bool
+	}{
+		{params: "units=3", want: risiko.RetreatThresholds{MinUnits: 3}},
+		{params: "ratio=0.5,odds=0.2", want: risiko.RetreatThresholds{MinRatio: 0.5, MinWinProbability: 0.2}},
+		{params: "odds=0", want: risiko.RetreatThresholds{}},
+		{params: "odds=1", want: risiko.RetreatThresholds{MinWinProbability: 1}},
+		{params: "", wantErr: true},
+		{params: "units=three", wantErr: true},
+		{params: "luck=7", wantErr: true},
+		{params: "units=-3", wantErr: true},
+		{params: "ratio=-1", wantErr: true},
+		{params: "ratio=Inf", wantErr: true},
+		{params: "odds=1.5", wantErr: true},
+		{params: "odds=-0.1", wantErr: true},
+		{params: "odds=NaN", wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.params, func(t *testing.T) {
+			got, err := parseRetreatThresholds(tc.params)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error but got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Expected %+v but got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestParseUnitsRange(t *testing.T) {
+	testCases := []struct {
+		s       string
+		want    risiko.UnitsRange
+		wantErr bool
+	}{
+		{s: "10..60/5", want: risiko.UnitsRange{From: 10, To: 60, Step: 5}},
+		{s: "1..30", want: risiko.UnitsRange{From: 1, To: 30, Step: 1}},
+		{s: "7", want: risiko.UnitsRange{From: 7, To: 7, Step: 1}},
+		{s: "a..b", wantErr: true},
+		{s: "1..30/x", wantErr: true},
+		{s: "30..1", wantErr: true},
+		{s: "1..30/0", wantErr: true},
+		{s: "-5..5", wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.s, func(t *testing.T) {
+			got, err := parseUnitsRange(tc.s)
+			if tc.wantErr {
+				if !isUsageError(err) {
+					t.Errorf("Expected a usage error but got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Expected %+v but got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestParseMatchups(t *testing.T) {
+	testCases := []struct {
+		s       string
+		want    risiko.Matchups
+		wantErr bool
+	}{
+		{s: "10v5,20v3", want: risiko.Matchups{{AttackerUnits: 10, DefenderUnits: 5}, {AttackerUnits: 20, DefenderUnits: 3}}},
+		{s: "2v1", want: risiko.Matchups{{AttackerUnits: 2, DefenderUnits: 1}}},
+		{s: "10x5", wantErr: true},
+		{s: "10v", wantErr: true},
+		{s: "10v5,", wantErr: true},
+		{s: "-1v3", wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.s, func(t *testing.T) {
+			got, err := parseMatchups(tc.s)
+			if tc.wantErr {
+				if !isUsageError(err) {
+					t.Errorf("Expected a usage error but got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("Expected %v but got %v", tc.want, got)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("Expected %v but got %v", tc.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	names, rules, err := parseRules("risiko,risk")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(names) != 2 || names[1] != "risk" || rules[1] != risiko.RiskRules {
+		t.Errorf("Expected risiko and risk rules but got %v %+v", names, rules)
+	}
+	for _, s := range []string{"monopoly", "risiko,monopoly", ""} {
+		if _, _, err := parseRules(s); !isUsageError(err) {
+			t.Errorf("Expected %q to be a usage error but got %v", s, err)
+		}
+	}
+}
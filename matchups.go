@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Ax6/risiko/pkg/risiko"
+)
+
+// Matchups of the sweep flags: the listed matchups, else the grid of the
+// attackers and defenders ranges, each defaulting to the square sweep
+func parseMatchupsFlags(rules risiko.Rules, unitsSweep int, attackersRange string, defendersRange string, matchupsList string) (risiko.Matchups, error) {
+	if matchupsList != "" {
+		return parseMatchups(matchupsList)
+	}
+	if attackersRange == "" && defendersRange == "" {
+		return risiko.SquareMatchups(rules, unitsSweep), nil
+	}
+	attackers := risiko.UnitsRange{From: rules.MinAttackUnits, To: unitsSweep, Step: 1}
+	defenders := risiko.UnitsRange{From: 1, To: unitsSweep, Step: 1}
+	var err error
+	if attackersRange != "" {
+		if attackers, err = parseUnitsRange(attackersRange); err != nil {
+			return nil, err
+		}
+	}
+	if defendersRange != "" {
+		if defenders, err = parseUnitsRange(defendersRange); err != nil {
+			return nil, err
+		}
+	}
+	matchups, err := risiko.GridMatchups(attackers, defenders)
+	if err != nil {
+		return nil, &usageError{err: err}
+	}
+	return matchups, nil
+}
+
+// Parses FROM..TO or FROM..TO/STEP, or a single number of units
+func parseUnitsRange(s string) (risiko.UnitsRange, error) {
+	bounds, stepText, hasStep := strings.Cut(s, "/")
+	fromText, toText, isRange := strings.Cut(bounds, "..")
+	if !isRange {
+		toText = fromText
+	}
+	if !hasStep {
+		stepText = "1"
+	}
+	from, errFrom := strconv.Atoi(fromText)
+	to, errTo := strconv.Atoi(toText)
+	step, errStep := strconv.Atoi(stepText)
+	if errFrom != nil || errTo != nil || errStep != nil {
+		return risiko.UnitsRange{}, usageErrorf("units range %q is not FROM..TO or FROM..TO/STEP", s)
+	}
+	r := risiko.UnitsRange{From: from, To: to, Step: step}
+	if err := r.Validate(); err != nil {
+		return risiko.UnitsRange{}, &usageError{err: err}
+	}
+	return r, nil
+}
+
+// Parses comma separated ATTACKERSvDEFENDERS battles, e.g. 10v5,20v3
+func parseMatchups(s string) (risiko.Matchups, error) {
+	matchups := risiko.Matchups{}
+	for _, matchup := range strings.Split(s, ",") {
+		attackersText, defendersText, ok := strings.Cut(matchup, "v")
+		attackers, errAtt := strconv.Atoi(attackersText)
+		defenders, errDef := strconv.Atoi(defendersText)
+		if !ok || errAtt != nil || errDef != nil {
+			return nil, usageErrorf("matchup %q is not ATTACKERSvDEFENDERS", matchup)
+		}
+		matchups = append(matchups, risiko.BattleState{AttackerUnits: attackers, DefenderUnits: defenders})
+	}
+	if err := matchups.Validate(); err != nil {
+		return nil, &usageError{err: err}
+	}
+	return matchups, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/Ax6/risiko/pkg/risiko"
+)
+
+// Prints the exact odds of a battle and the probability of every final state
+func runOdds(ctx context.Context, args []string) error {
+	flags := newFlagSet("odds", "Solves a battle exactly, both sides throwing the most dices, and prints its\nodds and the probability of every way it can end.")
+	nAttackers := flags.Int("attackers", 10, "attacking units")
+	nDefenders := flags.Int("defenders", 5, "defending units")
+	rulesName := flags.String("rules", "risiko", fmt.Sprintf("rules to play, any of %v", risiko.RulesPresetNames()))
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	rules, err := risiko.RulesPreset(*rulesName)
+	if err != nil {
+		return &usageError{err: err}
+	}
+	state := risiko.BattleState{AttackerUnits: *nAttackers, DefenderUnits: *nDefenders}
+	if state.AttackerUnits < 0 || state.DefenderUnits < 0 {
+		return usageErrorf("cannot solve battle with negative units %v", state)
+	}
+	solver, err := risiko.NewSolver(rules)
+	if err != nil {
+		return err
+	}
+	dist, err := solver.Solve(state)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%d attackers against %d defenders with %s rules\n\n", state.AttackerUnits, state.DefenderUnits, *rulesName)
+	fmt.Printf("Win probability          %.4f\n", dist.WinProbability())
+	fmt.Printf("Expected attackers left  %.4f\n", dist.ExpectedAttackerUnitsLeft())
+	fmt.Printf("Attackers left on win    %.4f\n", dist.AttackerUnitsLeftOnWin())
+	fmt.Printf("Expected defenders left  %.4f\n", dist.ExpectedDefenderUnitsLeft())
+	fmt.Printf("Median attackers left    %d\n\n", dist.AttackerUnitsPercentile(0.5))
+
+	fmt.Printf("%9s %9s %11s\n", "attackers", "defenders", "probability")
+	finals := slices.SortedFunc(maps.Keys(dist), func(a, b risiko.BattleState) int {
+		return cmp.Or(cmp.Compare(b.AttackerUnits, a.AttackerUnits), cmp.Compare(a.DefenderUnits, b.DefenderUnits))
+	})
+	for _, final := range finals {
+		fmt.Printf("%9d %9d %11.4f\n", final.AttackerUnits, final.DefenderUnits, dist[final])
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+// Goodness of fit tests telling whether dices roll as they're supposed to
+package fairness
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Ax6/risiko/pkg/risiko"
+)
+
+// Returned when there are too few rolls to run the tests
+var ErrNotEnoughRolls = errors.New("not enough rolls")
+
+// Outcome of a single statistical test
+type Result struct {
+	Name      string
+	Statistic float64
+	// Probability of a statistic at least as extreme with fair dices, small
+	// values mean the dices are unlikely to be fair
+	PValue float64
+}
+
+// Whether the test doesn't reject fairness at significance alpha
+func (r Result) Passed(alpha float64) bool {
+	return r.PValue >= alpha
+}
+
+// Outcome of every test on the same rolls
+type Report struct {
+	NRolls int
+	// How many times each face came out, face i+1 at index i
+	Counts            []int
+	ChiSquare         Result
+	KolmogorovSmirnov Result
+	SerialCorrelation Result
+}
+
+func (r Report) Results() []Result {
+	return []Result{r.ChiSquare, r.KolmogorovSmirnov, r.SerialCorrelation}
+}
+
+// Whether every test passed at significance alpha
+func (r Report) Passed(alpha float64) bool {
+	for _, result := range r.Results() {
+		if !result.Passed(alpha) {
+			return false
+		}
+	}
+	return true
+}
+
+// Runs every test on rolls in the order they came out, expecting them to
+// follow dice, a fair six sided dice when nil
+func Audit(rolls []int, dice *risiko.Dice) (Report, error) {
+	if dice == nil {
+		dice, _ = risiko.NewFairDice(6)
+	}
+	if len(rolls) < 2 {
+		return Report{}, fmt.Errorf("%w: need at least 2, got %d", ErrNotEnoughRolls, len(rolls))
+	}
+	counts := make([]int, dice.Faces())
+	for i, roll := range rolls {
+		if roll < 1 || roll > dice.Faces() {
+			return Report{}, fmt.Errorf("%w: roll %d is %d, the dice has %d faces", risiko.ErrInvalidRoll, i+1, roll, dice.Faces())
+		}
+		counts[roll-1]++
+	}
+	return Report{
+		NRolls:            len(rolls),
+		Counts:            counts,
+		ChiSquare:         ChiSquare(counts, dice),
+		KolmogorovSmirnov: KolmogorovSmirnov(counts, dice),
+		SerialCorrelation: SerialCorrelation(rolls),
+	}, nil
+}
+
+// Pearson's chi-square test of the face counts. Faces the dice never rolls
+// are left out, and fail the test as soon as they come out.
+func ChiSquare(counts []int, dice *risiko.Dice) Result {
+	n := float64(sum(counts))
+	statistic := 0.0
+	degrees := -1
+	for i, count := range counts {
+		expected := n * dice.Probability(i+1)
+		if expected == 0 {
+			if count > 0 {
+				return Result{Name: "chi-square", Statistic: math.Inf(1), PValue: 0}
+			}
+			continue
+		}
+		statistic += (float64(count) - expected) * (float64(count) - expected) / expected
+		degrees++
+	}
+	if degrees < 1 {
+		return Result{Name: "chi-square", Statistic: statistic, PValue: 1}
+	}
+	return Result{
+		Name:      "chi-square",
+		Statistic: statistic,
+		PValue:    upperRegularizedGamma(float64(degrees)/2, statistic/2),
+	}
+}
+
+// Kolmogorov-Smirnov test of the face counts, largest distance between the
+// observed and expected cumulative distribution. With discrete faces the
+// p-value is conservative.
+func KolmogorovSmirnov(counts []int, dice *risiko.Dice) Result {
+	n := float64(sum(counts))
+	distance := 0.0
+	observed, expected := 0.0, 0.0
+	for i, count := range counts {
+		observed += float64(count) / n
+		expected += dice.Probability(i + 1)
+		distance = max(distance, math.Abs(observed-expected))
+	}
+	// Stephens' approximation of the finite sample distribution
+	sqrtN := math.Sqrt(n)
+	lambda := (sqrtN + 0.12 + 0.11/sqrtN) * distance
+	return Result{Name: "kolmogorov-smirnov", Statistic: distance, PValue: kolmogorovQ(lambda)}
+}
+
+// Tests that each roll doesn't depend on the previous one through their lag 1
+// autocorrelation, which is close to normal for independent rolls
+func SerialCorrelation(rolls []int) Result {
+	n := float64(len(rolls))
+	mean := float64(sum(rolls)) / n
+	covariance, variance := 0.0, 0.0
+	for i, roll := range rolls {
+		deviation := float64(roll) - mean
+		variance += deviation * deviation
+		if i+1 < len(rolls) {
+			covariance += deviation * (float64(rolls[i+1]) - mean)
+		}
+	}
+	if variance == 0 {
+		// Always the same face, nothing to correlate
+		return Result{Name: "serial correlation", PValue: 1}
+	}
+	r := covariance / variance
+	z := (r + 1/n) * math.Sqrt(n)
+	return Result{Name: "serial correlation", Statistic: r, PValue: math.Erfc(math.Abs(z) / math.Sqrt2)}
+}
+
+// Rolls dices from gen one at a time, so that their order is the order they
+// came out in rather than sorted
+func Sample(gen risiko.DicesGenerator, nRolls int) ([]int, error) {
+	if nRolls < 1 {
+		return nil, fmt.Errorf("%w: cannot roll %d dices", ErrNotEnoughRolls, nRolls)
+	}
+	rolls := make([]int, 0, nRolls)
+	for range nRolls {
+		dices, err := gen(1)
+		if err != nil {
+			return nil, err
+		}
+		rolls = append(rolls, dices.Roll()...)
+	}
+	return rolls, nil
+}
+
+// Reads whitespace separated rolls from r, e.g. a log of physical rolls
+// written a roll per line
+func ReadRolls(r io.Reader) ([]int, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	rolls := []int{}
+	for _, field := range strings.Fields(string(data)) {
+		roll, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q is not a number", risiko.ErrInvalidRoll, field)
+		}
+		rolls = append(rolls, roll)
+	}
+	return rolls, nil
+}
+
+func sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
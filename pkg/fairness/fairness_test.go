@@ -0,0 +1,151 @@
+package fairness
+
+import (
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Ax6/risiko/pkg/risiko"
+)
+
+func TestAudit(t *testing.T) {
+	const alpha = 0.001
+	nRolls := 20000
+	loaded, err := risiko.NewDice(1, 1, 1, 1, 1, 1.3)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	repeating := make([]int, nRolls)
+	for i := range repeating {
+		repeating[i] = i%6 + 1
+	}
+
+	testCases := []struct {
+		name       string
+		rolls      func(t *testing.T) []int
+		dice       *risiko.Dice
+		wantPassed []bool
+	}{
+		{
+			name:       "fair",
+			rolls:      sampleTestRolls(risiko.NewSeededDicesGen(1, 0), nRolls),
+			wantPassed: []bool{true, true, true},
+		},
+		{
+			name:       "loaded",
+			rolls:      sampleTestRolls(risiko.NewCustomDicesGen(rand.NewPCG(1, 2), loaded), nRolls),
+			wantPassed: []bool{false, false, true},
+		},
+		{
+			name:       "loaded as expected",
+			rolls:      sampleTestRolls(risiko.NewCustomDicesGen(rand.NewPCG(1, 2), loaded), nRolls),
+			dice:       loaded,
+			wantPassed: []bool{true, true, true},
+		},
+		{
+			name:       "repeating faces",
+			rolls:      func(*testing.T) []int { return repeating },
+			wantPassed: []bool{true, true, false},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			report, err := Audit(tc.rolls(t), tc.dice)
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			for i, result := range report.Results() {
+				if result.Passed(alpha) != tc.wantPassed[i] {
+					t.Errorf("Expected %s passed to be %v but got p-value %g", result.Name, tc.wantPassed[i], result.PValue)
+				}
+			}
+			if want := !slices.Contains(tc.wantPassed, false); report.Passed(alpha) != want {
+				t.Errorf("Expected report passed to be %v", want)
+			}
+		})
+	}
+
+	if _, err := Audit([]int{1}, nil); !errors.Is(err, ErrNotEnoughRolls) {
+		t.Errorf("Expected a single roll to fail but got %v", err)
+	}
+	if _, err := Audit([]int{1, 7}, nil); !errors.Is(err, risiko.ErrInvalidRoll) {
+		t.Errorf("Expected face 7 to fail but got %v", err)
+	}
+}
+
+func sampleTestRolls(gen risiko.DicesGenerator, nRolls int) func(t *testing.T) []int {
+	return func(t *testing.T) []int {
+		rolls, err := Sample(gen, nRolls)
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		return rolls
+	}
+}
+
+func TestChiSquareImpossibleFace(t *testing.T) {
+	dice, err := risiko.NewDice(1, 1, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if got := ChiSquare([]int{10, 10, 0}, dice); got.PValue < 0.99 {
+		t.Errorf("Expected even counts to pass but got p-value %g", got.PValue)
+	}
+	if got := ChiSquare([]int{10, 10, 1}, dice); got.PValue != 0 {
+		t.Errorf("Expected impossible face to fail but got p-value %g", got.PValue)
+	}
+}
+
+func TestReadRolls(t *testing.T) {
+	got, err := ReadRolls(strings.NewReader("6 4 1\n\n3\t2\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if want := []int{6, 4, 1, 3, 2}; !slices.Equal(got, want) {
+		t.Errorf("Expected %v but got %v", want, got)
+	}
+	if _, err := ReadRolls(strings.NewReader("6 four")); !errors.Is(err, risiko.ErrInvalidRoll) {
+		t.Errorf("Expected invalid roll but got %v", err)
+	}
+}
+
+// The dices of the risiko package, seeded so the test is deterministic
+func TestAuditDicesGenerators(t *testing.T) {
+	eightSided, err := risiko.NewFairDice(8)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	testCases := []struct {
+		name string
+		gen  risiko.DicesGenerator
+		dice *risiko.Dice
+	}{
+		{name: "seeded", gen: risiko.NewSeededDicesGen(42, 0)},
+		{name: "pcg", gen: risiko.NewDicesGen(rand.NewPCG(3, 4))},
+		{name: "chacha8", gen: risiko.NewDicesGen(rand.NewChaCha8([32]byte{1}))},
+		{name: "eight sided", gen: risiko.NewCustomDicesGen(rand.NewPCG(5, 6), eightSided), dice: eightSided},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			report, err := Audit(sampleTestRolls(tc.gen, 50000)(t), tc.dice)
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			for _, result := range report.Results() {
+				if !result.Passed(0.001) {
+					t.Errorf("Expected %s to pass but got p-value %g", result.Name, result.PValue)
+				}
+			}
+		})
+	}
+}
+
+func TestSampleNoRolls(t *testing.T) {
+	for _, nRolls := range []int{0, -1} {
+		if _, err := Sample(risiko.NewSeededDicesGen(1, 0), nRolls); !errors.Is(err, ErrNotEnoughRolls) {
+			t.Errorf("Expected %d rolls to fail with not enough rolls but got %v", nRolls, err)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package fairness
+
+import "math"
+
+// Upper regularized incomplete gamma function Q(a, x), the chi-square
+// survival function with 2a degrees of freedom at 2x
+func upperRegularizedGamma(a float64, x float64) float64 {
+	if x <= 0 {
+		return 1
+	}
+	lga, _ := math.Lgamma(a)
+	front := math.Exp(-x + a*math.Log(x) - lga)
+	// The series converges quickly below the mean, the continued fraction
+	// above it
+	if x < a+1 {
+		term := 1 / a
+		total := term
+		for n := 1; n <= 1000; n++ {
+			term *= x / (a + float64(n))
+			total += term
+			if math.Abs(term) < math.Abs(total)*1e-15 {
+				break
+			}
+		}
+		return max(0, 1-front*total)
+	}
+	return front * gammaContinuedFraction(a, x)
+}
+
+// Evaluates the continued fraction of the upper incomplete gamma function with
+// the modified Lentz method
+func gammaContinuedFraction(a float64, x float64) float64 {
+	const epsilon = 1e-15
+	const tiny = 1e-300
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for i := 1; i <= 1000; i++ {
+		an := -float64(i) * (float64(i) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return h
+}
+
+// Survival function of the Kolmogorov distribution
+func kolmogorovQ(lambda float64) float64 {
+	if lambda < 0.2 {
+		// The series doesn't converge, but the distance is tiny anyway
+		return 1
+	}
+	total := 0.0
+	sign := 1.0
+	for j := 1; j <= 100; j++ {
+		term := sign * math.Exp(-2*float64(j*j)*lambda*lambda)
+		total += term
+		if math.Abs(term) < 1e-15 {
+			break
+		}
+		sign = -sign
+	}
+	return min(1, max(0, 2*total))
+}
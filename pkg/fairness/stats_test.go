@@ -0,0 +1,44 @@
+package fairness
+
+import (
+	"math"
+	"testing"
+)
+
+func TestUpperRegularizedGamma(t *testing.T) {
+	testCases := []struct {
+		degrees   float64
+		statistic float64
+		want      float64
+	}{
+		// Critical values of the chi-square distribution
+		{degrees: 1, statistic: 3.841459, want: 0.05},
+		{degrees: 5, statistic: 11.070498, want: 0.05},
+		{degrees: 5, statistic: 15.086272, want: 0.01},
+		{degrees: 20, statistic: 12.442609, want: 0.9},
+		{degrees: 2, statistic: 0, want: 1},
+	}
+	for _, tc := range testCases {
+		got := upperRegularizedGamma(tc.degrees/2, tc.statistic/2)
+		if math.Abs(got-tc.want) > 1e-6 {
+			t.Errorf("Expected p-value %f with %v degrees at %f but got %f", tc.want, tc.degrees, tc.statistic, got)
+		}
+	}
+}
+
+func TestKolmogorovQ(t *testing.T) {
+	testCases := []struct {
+		lambda float64
+		want   float64
+	}{
+		// Critical values of the Kolmogorov distribution
+		{lambda: 1.358099, want: 0.05},
+		{lambda: 1.627624, want: 0.01},
+		{lambda: 0.1, want: 1},
+	}
+	for _, tc := range testCases {
+		if got := kolmogorovQ(tc.lambda); math.Abs(got-tc.want) > 1e-5 {
+			t.Errorf("Expected p-value %f at %f but got %f", tc.want, tc.lambda, got)
+		}
+	}
+}
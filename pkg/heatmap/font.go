@@ -0,0 +1,98 @@
+package heatmap
+
+import (
+	"image"
+	"image/color"
+	"strings"
+)
+
+// Glyphs are 5 by 7 pixels, followed by a pixel of spacing
+const (
+	glyphWidth   = 5
+	glyphHeight  = 7
+	glyphAdvance = glyphWidth + 1
+)
+
+// Pixels of each glyph, a row per line with # for ink. Lowercase letters are
+// drawn uppercase and unknown characters are left blank.
+var glyphs = map[rune]string{
+	'A': ".###.|#...#|#...#|#####|#...#|#...#|#...#",
+	'B': "####.|#...#|#...#|####.|#...#|#...#|####.",
+	'C': ".###.|#...#|#....|#....|#....|#...#|.###.",
+	'D': "####.|#...#|#...#|#...#|#...#|#...#|####.",
+	'E': "#####|#....|#....|####.|#....|#....|#####",
+	'F': "#####|#....|#....|####.|#....|#....|#....",
+	'G': ".###.|#...#|#....|#.###|#...#|#...#|.####",
+	'H': "#...#|#...#|#...#|#####|#...#|#...#|#...#",
+	'I': ".###.|..#..|..#..|..#..|..#..|..#..|.###.",
+	'J': "..###|...#.|...#.|...#.|...#.|#..#.|.##..",
+	'K': "#...#|#..#.|#.#..|##...|#.#..|#..#.|#...#",
+	'L': "#....|#....|#....|#....|#....|#....|#####",
+	'M': "#...#|##.##|#.#.#|#.#.#|#...#|#...#|#...#",
+	'N': "#...#|#...#|##..#|#.#.#|#..##|#...#|#...#",
+	'O': ".###.|#...#|#...#|#...#|#...#|#...#|.###.",
+	'P': "####.|#...#|#...#|####.|#....|#....|#....",
+	'Q': ".###.|#...#|#...#|#...#|#.#.#|#..#.|.##.#",
+	'R': "####.|#...#|#...#|####.|#.#..|#..#.|#...#",
+	'S': ".####|#....|#....|.###.|....#|....#|####.",
+	'T': "#####|..#..|..#..|..#..|..#..|..#..|..#..",
+	'U': "#...#|#...#|#...#|#...#|#...#|#...#|.###.",
+	'V': "#...#|#...#|#...#|#...#|#...#|.#.#.|..#..",
+	'W': "#...#|#...#|#...#|#.#.#|#.#.#|#.#.#|.#.#.",
+	'X': "#...#|#...#|.#.#.|..#..|.#.#.|#...#|#...#",
+	'Y': "#...#|#...#|.#.#.|..#..|..#..|..#..|..#..",
+	'Z': "#####|....#|...#.|..#..|.#...|#....|#####",
+	'0': ".###.|#...#|#..##|#.#.#|##..#|#...#|.###.",
+	'1': "..#..|.##..|..#..|..#..|..#..|..#..|.###.",
+	'2': ".###.|#...#|....#|...#.|..#..|.#...|#####",
+	'3': "####.|....#|....#|.###.|....#|....#|####.",
+	'4': "...#.|..##.|.#.#.|#..#.|#####|...#.|...#.",
+	'5': "#####|#....|####.|....#|....#|#...#|.###.",
+	'6': "..##.|.#...|#....|####.|#...#|#...#|.###.",
+	'7': "#####|....#|...#.|..#..|.#...|.#...|.#...",
+	'8': ".###.|#...#|#...#|.###.|#...#|#...#|.###.",
+	'9': ".###.|#...#|#...#|.####|....#|...#.|.##..",
+	'.': ".....|.....|.....|.....|.....|.##..|.##..",
+	',': ".....|.....|.....|.....|.##..|..#..|.#...",
+	'%': "##...|##..#|...#.|..#..|.#...|#..##|...##",
+	'-': ".....|.....|.....|#####|.....|.....|.....",
+	'(': "...#.|..#..|.#...|.#...|.#...|..#..|...#.",
+	')': ".#...|..#..|...#.|...#.|...#.|..#..|.#...",
+	'!': "..#..|..#..|..#..|..#..|..#..|.....|..#..",
+}
+
+// Width in pixels of text drawn at scale
+func textWidth(text string, scale int) int {
+	if text == "" {
+		return 0
+	}
+	return (len([]rune(text))*glyphAdvance - 1) * scale
+}
+
+// Draws text with its top left corner at x, y. Vertical text reads from bottom
+// to top with its bottom left corner at x, y.
+func drawText(img *image.RGBA, x int, y int, text string, scale int, vertical bool, c color.Color) {
+	for i, r := range []rune(strings.ToUpper(text)) {
+		glyph, ok := glyphs[r]
+		if !ok {
+			continue
+		}
+		for gy, row := range strings.Split(glyph, "|") {
+			for gx, pixel := range row {
+				if pixel != '#' {
+					continue
+				}
+				px, py := (i*glyphAdvance+gx)*scale, gy*scale
+				for sy := range scale {
+					for sx := range scale {
+						if vertical {
+							img.Set(x+py+sy, y-px-sx, c)
+						} else {
+							img.Set(x+px+sx, y+py+sy, c)
+						}
+					}
+				}
+			}
+		}
+	}
+}
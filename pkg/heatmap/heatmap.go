@@ -0,0 +1,145 @@
+// Colour mapped tables of numbers rendered as PNG or SVG without any
+// dependency outside the standard library
+package heatmap
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+)
+
+// Table of values with a row per Rows label and a column per Columns label.
+// Cells without a value hold NaN and are drawn grey.
+type Heatmap struct {
+	Title   string
+	XLabel  string
+	YLabel  string
+	Columns []int
+	Rows    []int
+	// Indexed by row then column
+	Values [][]float64
+	// Values mapped to the ends of the colour scale, the range of the values
+	// when both are zero
+	Min float64
+	Max float64
+	// Text written in each cell, two decimals when nil
+	Annotate func(float64) string
+	// Text shown when hovering a cell of the SVG image, none when nil or empty
+	Tooltip func(row int, column int) string
+}
+
+var (
+	lowColour   = color.RGBA{R: 33, G: 102, B: 172, A: 255}
+	midColour   = color.RGBA{R: 247, G: 247, B: 247, A: 255}
+	highColour  = color.RGBA{R: 178, G: 24, B: 43, A: 255}
+	emptyColour = color.RGBA{R: 210, G: 210, B: 210, A: 255}
+)
+
+func (h *Heatmap) validate() error {
+	if len(h.Values) != len(h.Rows) {
+		return fmt.Errorf("heatmap has %d rows of values and %d row labels", len(h.Values), len(h.Rows))
+	}
+	for i, row := range h.Values {
+		if len(row) != len(h.Columns) {
+			return fmt.Errorf("heatmap row %d has %d values and %d column labels", i, len(row), len(h.Columns))
+		}
+	}
+	return nil
+}
+
+// Ends of the colour scale
+func (h *Heatmap) scale() (float64, float64) {
+	if h.Min != 0 || h.Max != 0 {
+		return h.Min, h.Max
+	}
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, row := range h.Values {
+		for _, v := range row {
+			if !math.IsNaN(v) {
+				low, high = min(low, v), max(high, v)
+			}
+		}
+	}
+	if math.IsInf(low, 0) {
+		return 0, 1
+	}
+	return low, high
+}
+
+// Diverging colour of v, blue at the low end, white in the middle and red at
+// the high end
+func (h *Heatmap) colour(v float64) color.RGBA {
+	if math.IsNaN(v) {
+		return emptyColour
+	}
+	low, high := h.scale()
+	t := 0.5
+	if high > low {
+		t = min(1, max(0, (v-low)/(high-low)))
+	}
+	if t < 0.5 {
+		return mix(lowColour, midColour, t*2)
+	}
+	return mix(midColour, highColour, t*2-1)
+}
+
+func mix(a color.RGBA, b color.RGBA, t float64) color.RGBA {
+	channel := func(x, y uint8) uint8 {
+		return uint8(math.Round(float64(x) + (float64(y)-float64(x))*t))
+	}
+	return color.RGBA{R: channel(a.R, b.R), G: channel(a.G, b.G), B: channel(a.B, b.B), A: 255}
+}
+
+// Black on light colours, white on dark ones
+func textColour(background color.RGBA) color.RGBA {
+	luminance := 0.299*float64(background.R) + 0.587*float64(background.G) + 0.114*float64(background.B)
+	if luminance > 140 {
+		return color.RGBA{A: 255}
+	}
+	return color.RGBA{R: 255, G: 255, B: 255, A: 255}
+}
+
+func (h *Heatmap) annotation(v float64) string {
+	if math.IsNaN(v) {
+		return ""
+	}
+	if h.Annotate != nil {
+		return h.Annotate(v)
+	}
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// Positions of the parts of the heatmap in pixels
+type layout struct {
+	cellWidth  int
+	cellHeight int
+	// Top left corner of the grid
+	left int
+	top  int
+	// Whole image
+	width  int
+	height int
+}
+
+func (h *Heatmap) layout() layout {
+	widest := 0
+	for _, row := range h.Values {
+		for _, v := range row {
+			widest = max(widest, len(h.annotation(v)))
+		}
+	}
+	for _, label := range h.Columns {
+		widest = max(widest, len(strconv.Itoa(label)))
+	}
+	l := layout{
+		cellWidth:  max(28, widest*glyphAdvance+8),
+		cellHeight: 22,
+		left:       60,
+		top:        40,
+	}
+	l.width = l.left + len(h.Columns)*l.cellWidth + 20
+	l.height = l.top + len(h.Rows)*l.cellHeight + 50
+	l.width = max(l.width, 2*len(h.Title)*glyphAdvance+40)
+	return l
+}
@@ -0,0 +1,141 @@
+package heatmap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"image/png"
+	"math"
+	"strings"
+	"testing"
+)
+
+func newTestHeatmap() *Heatmap {
+	return &Heatmap{
+		Title:   "Win probability",
+		XLabel:  "Attackers",
+		YLabel:  "Defenders",
+		Columns: []int{2, 3, 4},
+		Rows:    []int{1, 2},
+		Values: [][]float64{
+			{0, 0.5, 1},
+			{0.25, math.NaN(), 0.75},
+		},
+	}
+}
+
+func TestColour(t *testing.T) {
+	h := newTestHeatmap()
+	testCases := []struct {
+		value float64
+		want  color.RGBA
+	}{
+		{value: 0, want: lowColour},
+		{value: 0.5, want: midColour},
+		{value: 1, want: highColour},
+		{value: 2, want: highColour},
+		{value: math.NaN(), want: emptyColour},
+	}
+	for _, tc := range testCases {
+		if got := h.colour(tc.value); got != tc.want {
+			t.Errorf("Expected colour %v for %f but got %v", tc.want, tc.value, got)
+		}
+	}
+
+	h.Min, h.Max = 0, 10
+	if got := h.colour(5); got != midColour {
+		t.Errorf("Expected the middle of a fixed scale to be %v but got %v", midColour, got)
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	h := newTestHeatmap()
+	var buf bytes.Buffer
+	if err := h.WritePNG(&buf); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	l := h.layout()
+	if img.Bounds().Dx() != l.width || img.Bounds().Dy() != l.height {
+		t.Errorf("Expected a %dx%d image but got %v", l.width, l.height, img.Bounds())
+	}
+	// Corners of the cells are never covered by their annotation
+	for i, row := range h.Values {
+		for j, v := range row {
+			got := color.RGBAModel.Convert(img.At(l.left+j*l.cellWidth+1, l.top+i*l.cellHeight+1))
+			if want := h.colour(v); got != want {
+				t.Errorf("Expected cell %d,%d to be %v but got %v", i, j, want, got)
+			}
+		}
+	}
+}
+
+func TestWriteSVG(t *testing.T) {
+	h := newTestHeatmap()
+	h.Title = "Attackers < Defenders"
+	h.Annotate = func(v float64) string { return "~" }
+	h.Tooltip = func(row int, column int) string {
+		if row == 0 {
+			return fmt.Sprintf("%d & %d", h.Rows[row], h.Columns[column])
+		}
+		return ""
+	}
+	var buf bytes.Buffer
+	if err := h.WriteSVG(&buf); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	decoder := xml.NewDecoder(&buf)
+	nRects, nAnnotations, nTooltips := 0, 0, 0
+	inTitle := false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			if token.Name.Local == "rect" {
+				nRects++
+			}
+			inTitle = token.Name.Local == "title"
+		case xml.EndElement:
+			inTitle = false
+		case xml.CharData:
+			if inTitle && strings.Contains(string(token), " & ") {
+				nTooltips++
+			}
+			if strings.TrimSpace(string(token)) == "~" {
+				nAnnotations++
+			}
+		}
+	}
+	// Background and one per cell
+	if nRects != 7 {
+		t.Errorf("Expected 7 rects but got %d", nRects)
+	}
+	// The empty cell has no annotation
+	if nAnnotations != 5 {
+		t.Errorf("Expected 5 annotations but got %d", nAnnotations)
+	}
+	// Only the first row has tooltips
+	if nTooltips != len(h.Columns) {
+		t.Errorf("Expected %d tooltips but got %d", len(h.Columns), nTooltips)
+	}
+}
+
+func TestInvalidHeatmap(t *testing.T) {
+	h := newTestHeatmap()
+	h.Rows = h.Rows[:1]
+	if err := h.WritePNG(&bytes.Buffer{}); err == nil {
+		t.Errorf("Expected mismatched rows to fail")
+	}
+	h = newTestHeatmap()
+	h.Columns = h.Columns[:2]
+	if err := h.WriteSVG(&bytes.Buffer{}); err == nil {
+		t.Errorf("Expected mismatched columns to fail")
+	}
+}
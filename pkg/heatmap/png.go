@@ -0,0 +1,59 @@
+package heatmap
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"strconv"
+)
+
+// Renders the heatmap as a PNG image
+func (h *Heatmap) WritePNG(w io.Writer) error {
+	img, err := h.Image()
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
+
+// Renders the heatmap as an image
+func (h *Heatmap) Image() (*image.RGBA, error) {
+	if err := h.validate(); err != nil {
+		return nil, err
+	}
+	l := h.layout()
+	img := image.NewRGBA(image.Rect(0, 0, l.width, l.height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	black := color.RGBA{A: 255}
+
+	// Title centred above the grid
+	drawText(img, (l.width-textWidth(h.Title, 2))/2, 12, h.Title, 2, false, black)
+
+	for i, row := range h.Values {
+		y := l.top + i*l.cellHeight
+		label := strconv.Itoa(h.Rows[i])
+		drawText(img, l.left-6-textWidth(label, 1), y+(l.cellHeight-glyphHeight)/2, label, 1, false, black)
+		for j, v := range row {
+			x := l.left + j*l.cellWidth
+			background := h.colour(v)
+			cell := image.Rect(x, y, x+l.cellWidth, y+l.cellHeight)
+			draw.Draw(img, cell, image.NewUniform(background), image.Point{}, draw.Src)
+			text := h.annotation(v)
+			drawText(img, x+(l.cellWidth-textWidth(text, 1))/2, y+(l.cellHeight-glyphHeight)/2, text, 1, false, textColour(background))
+		}
+	}
+
+	// Column labels below the grid, then the axis labels
+	bottom := l.top + len(h.Rows)*l.cellHeight
+	for j, column := range h.Columns {
+		label := strconv.Itoa(column)
+		drawText(img, l.left+j*l.cellWidth+(l.cellWidth-textWidth(label, 1))/2, bottom+6, label, 1, false, black)
+	}
+	gridWidth := len(h.Columns) * l.cellWidth
+	drawText(img, l.left+(gridWidth-textWidth(h.XLabel, 1))/2, bottom+24, h.XLabel, 1, false, black)
+	gridHeight := len(h.Rows) * l.cellHeight
+	drawText(img, 12, l.top+(gridHeight+textWidth(h.YLabel, 1))/2, h.YLabel, 1, true, black)
+	return img, nil
+}
@@ -0,0 +1,60 @@
+package heatmap
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"image/color"
+	"io"
+)
+
+// Renders the heatmap as an SVG image with the same layout as the PNG one
+func (h *Heatmap) WriteSVG(w io.Writer) error {
+	if err := h.validate(); err != nil {
+		return err
+	}
+	l := h.layout()
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif">`+"\n", l.width, l.height, l.width, l.height)
+	fmt.Fprintf(bw, `<rect width="%d" height="%d" fill="white"/>`+"\n", l.width, l.height)
+	fmt.Fprintf(bw, `<text x="%d" y="26" font-size="16" text-anchor="middle">%s</text>`+"\n", l.width/2, html.EscapeString(h.Title))
+
+	for i, row := range h.Values {
+		y := l.top + i*l.cellHeight
+		fmt.Fprintf(bw, `<text x="%d" y="%d" font-size="11" text-anchor="end" dominant-baseline="middle">%d</text>`+"\n", l.left-6, y+l.cellHeight/2, h.Rows[i])
+		for j, v := range row {
+			x := l.left + j*l.cellWidth
+			background := h.colour(v)
+			if tooltip := h.tooltip(i, j); tooltip != "" {
+				fmt.Fprintf(bw, `<rect class="cell" x="%d" y="%d" width="%d" height="%d" fill="%s"><title>%s</title></rect>`+"\n", x, y, l.cellWidth, l.cellHeight, hexColour(background), html.EscapeString(tooltip))
+			} else {
+				fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n", x, y, l.cellWidth, l.cellHeight, hexColour(background))
+			}
+			if text := h.annotation(v); text != "" {
+				fmt.Fprintf(bw, `<text x="%d" y="%d" font-size="10" text-anchor="middle" dominant-baseline="middle" fill="%s">%s</text>`+"\n", x+l.cellWidth/2, y+l.cellHeight/2, hexColour(textColour(background)), html.EscapeString(text))
+			}
+		}
+	}
+
+	bottom := l.top + len(h.Rows)*l.cellHeight
+	for j, column := range h.Columns {
+		fmt.Fprintf(bw, `<text x="%d" y="%d" font-size="11" text-anchor="middle">%d</text>`+"\n", l.left+j*l.cellWidth+l.cellWidth/2, bottom+14, column)
+	}
+	gridWidth := len(h.Columns) * l.cellWidth
+	gridHeight := len(h.Rows) * l.cellHeight
+	fmt.Fprintf(bw, `<text x="%d" y="%d" font-size="12" text-anchor="middle">%s</text>`+"\n", l.left+gridWidth/2, bottom+34, html.EscapeString(h.XLabel))
+	fmt.Fprintf(bw, `<text x="18" y="%d" font-size="12" text-anchor="middle" transform="rotate(-90 18 %d)">%s</text>`+"\n", l.top+gridHeight/2, l.top+gridHeight/2, html.EscapeString(h.YLabel))
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+func (h *Heatmap) tooltip(row int, column int) string {
+	if h.Tooltip == nil {
+		return ""
+	}
+	return h.Tooltip(row, column)
+}
+
+func hexColour(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -2,139 +2,213 @@
 package risiko
 
 import (
-	"context"
+	"errors"
 	"fmt"
+	"sync"
 )
 
-type SimulationResult struct {
-	NRuns                  int
-	NAttackerWon           int
-	TotalAttackerUnitsLeft int
+// Summary of the battles starting from the same state, either simulated or
+// solved exactly
+type Outcome interface {
+	WinProbability() float64
+	ExpectedAttackerUnitsLeft() float64
+	AttackerUnitsLeftOnWin() float64
 }
 
-type SimulationSweep = map[int]map[int]SimulationResult
-
 type BattleState struct {
-	AttackerUnits int
-	DefenderUnits int
+	AttackerUnits int `json:"attacker_units"`
+	DefenderUnits int `json:"defender_units"`
+}
+
+// The attacker wins when there are no defenders left
+func (s BattleState) AttackerWon() bool {
+	return s.DefenderUnits <= 0
 }
 
-type BattleStrategy = func() EngageStrategy
+// Outcome of a battle including the armies moved after a conquest
+type BattleResult struct {
+	// State when the fighting stopped, before any army moved
+	Final BattleState
+	// Dices thrown by the attacker in the last engagement, 0 if there was none
+	LastAttackDices int
+	// Set only when the attacker won with units left to move into the
+	// conquered territory
+	Conquest *Conquest
+}
+
+// Starts the strategy of a side for a battle played with the given rules
+type BattleStrategy = func(Rules) EngageStrategy
 
 func NewMaxAttackersStrategy(gen DicesGenerator) BattleStrategy {
-	return func() EngageStrategy {
-		return &maxAttackers{genDices: gen}
+	return func(rules Rules) EngageStrategy {
+		return &maxAttackers{rules: rules, genDices: gen}
+	}
+}
+
+// Attacks with maximum units like NewMaxAttackersStrategy, but retreats as
+// soon as the battle state drops below any of the thresholds
+func NewRetreatAttackersStrategy(gen DicesGenerator, thresholds RetreatThresholds) BattleStrategy {
+	solvers := &retreatSolvers{pools: map[Rules]*sync.Pool{}}
+	return func(rules Rules) EngageStrategy {
+		return &retreatAttackers{
+			maxAttackers: maxAttackers{rules: rules, genDices: gen},
+			thresholds:   thresholds,
+			solvers:      solvers,
+		}
+	}
+}
+
+// Attacks with as many dices as policy decides
+func NewPolicyAttackersStrategy(gen DicesGenerator, policy DicesPolicy) BattleStrategy {
+	return func(rules Rules) EngageStrategy {
+		return &policyDices{rules: rules, side: AttackerSide, policy: policy, genDices: gen}
+	}
+}
+
+// Defends with as many dices as policy decides
+func NewPolicyDefendersStrategy(gen DicesGenerator, policy DicesPolicy) BattleStrategy {
+	return func(rules Rules) EngageStrategy {
+		return &policyDices{rules: rules, side: DefenderSide, policy: policy, genDices: gen}
 	}
 }
 
 func NewMaxDefendersStrategy(gen DicesGenerator) BattleStrategy {
-	return func() EngageStrategy {
-		return &maxDefenders{genDices: gen}
+	return func(rules Rules) EngageStrategy {
+		return &maxDefenders{rules: rules, genDices: gen}
 	}
 }
 
-func Battle(state BattleState, attacker BattleStrategy, defender BattleStrategy) (BattleState, error) {
-	att := attacker()
-	def := defender()
-	for state.AttackerUnits >= ENGAGE_RULE_MIN_ATTACK && state.DefenderUnits > 0 {
+// Plays a battle until the defender has no units left, the attacker doesn't
+// have enough units to attack or it retreats. Returns the final state.
+func Battle(rules Rules, state BattleState, attacker BattleStrategy, defender BattleStrategy) (BattleState, error) {
+	final, _, err := fight(rules, state, attacker, defender, nil)
+	return final, err
+}
+
+// Plays a battle like Battle, passing every round to recorder as it's played
+func BattleRecorded(rules Rules, state BattleState, attacker BattleStrategy, defender BattleStrategy, recorder Recorder) (BattleState, error) {
+	final, _, err := fight(rules, state, attacker, defender, recorder)
+	return final, err
+}
+
+// Plays a battle like Battle and, when the attacker wins, moves its armies
+// into the conquered territory as decided by conquest
+func BattleAndConquer(rules Rules, state BattleState, attacker BattleStrategy, defender BattleStrategy, conquest ConquestStrategy) (BattleResult, error) {
+	final, lastAttackDices, err := fight(rules, state, attacker, defender, nil)
+	if err != nil {
+		return BattleResult{}, err
+	}
+	result := BattleResult{Final: final, LastAttackDices: lastAttackDices}
+	// Without units to move in the territory stays empty, a failed conquest
+	if final.AttackerWon() && final.AttackerUnits > rules.UnitsStayBehind {
+		moved, err := Conquer(rules, final, lastAttackDices, conquest)
+		if err != nil {
+			return BattleResult{}, err
+		}
+		result.Conquest = &moved
+	}
+	return result, nil
+}
+
+// Returns the final state and how many dices the attacker threw last. The
+// recorder is optional, and also gets the ending when it's an EndRecorder.
+func fight(rules Rules, state BattleState, attacker BattleStrategy, defender BattleStrategy, recorder Recorder) (BattleState, int, error) {
+	if err := rules.Validate(); err != nil {
+		return BattleState{}, 0, err
+	}
+	att := attacker(rules)
+	def := defender(rules)
+	lastAttackDices := 0
+	nRounds := 0
+	retreated := false
+	for state.AttackerUnits >= rules.MinAttackUnits && state.DefenderUnits > 0 {
 		att.UpdateState(state)
 		def.UpdateState(state)
 
 		attackerThrows, err := att.GetDices()
-		if err != nil {
-			return BattleState{}, fmt.Errorf("oh no %v", err)
+		if errors.Is(err, ErrRetreat) {
+			retreated = true
+			break
+		} else if err != nil {
+			return BattleState{}, 0, &StrategyError{Side: AttackerSide, State: state, Err: err}
 		}
 
 		defenderThrows, err := def.GetDices()
 		if err != nil {
-			return BattleState{}, fmt.Errorf("oh no %v", err)
+			return BattleState{}, 0, &StrategyError{Side: DefenderSide, State: state, Err: err}
+		}
+
+		if err := validateDices(rules, AttackerSide, state, attackerThrows); err != nil {
+			return BattleState{}, 0, err
+		}
+		if err := validateDices(rules, DefenderSide, state, defenderThrows); err != nil {
+			return BattleState{}, 0, err
 		}
 
-		attackerLoss, defenderLoss := engage(attackerThrows, defenderThrows)
-		state = BattleState{
+		lastAttackDices = attackerThrows.Count()
+		attackerRoll, err := rollDices(rules, AttackerSide, state, attackerThrows)
+		if err != nil {
+			return BattleState{}, 0, err
+		}
+		defenderRoll, err := rollDices(rules, DefenderSide, state, defenderThrows)
+		if err != nil {
+			return BattleState{}, 0, err
+		}
+		attackerLoss, defenderLoss := compareThrows(rules, attackerRoll, defenderRoll)
+		next := BattleState{
 			AttackerUnits: state.AttackerUnits - attackerLoss,
 			DefenderUnits: state.DefenderUnits - defenderLoss,
 		}
+		nRounds++
+		if recorder != nil {
+			recorder.RecordRound(newRound(rules, nRounds, state, next, attackerRoll, defenderRoll))
+		}
+		state = next
 	}
-	return state, nil
-}
-
-func Simulate(ctx context.Context, nRuns int, nUnitsSweep int, attackerStrategy BattleStrategy, defenderStrategy BattleStrategy) (SimulationSweep, error) {
-	simsCount := 0
-	simResult := SimulationSweep{}
-	ch := make(chan []*BattleState)
-	chErr := make(chan error)
-	defer close(ch)
-	defer close(chErr)
-
-	go func() {
-		for nDefenders := 1; nDefenders <= nUnitsSweep; nDefenders++ {
-			for nAttackers := ENGAGE_RULE_MIN_ATTACK; nAttackers <= nUnitsSweep; nAttackers++ {
-				go func(nAtt int, nDef int) {
-					for i := 0; i < nRuns; i++ {
-						initialState := BattleState{
-							AttackerUnits: nAtt,
-							DefenderUnits: nDef,
-						}
-						finalState, err := Battle(initialState, attackerStrategy, defenderStrategy)
-						if finalState.AttackerUnits < 0 {
-							fmt.Printf("WOWOWOWO %v", finalState)
-						}
-						if err != nil {
-							chErr <- err
-						} else {
-							ch <- []*BattleState{&initialState, &finalState}
-						}
-					}
-				}(nAttackers, nDefenders)
-			}
+	if recorder, ok := recorder.(EndRecorder); ok {
+		ending := Ending{End: EndLost, Final: state, Rounds: nRounds}
+		if retreated {
+			ending.End = EndRetreated
+		} else if state.AttackerWon() {
+			ending.End = EndWon
 		}
-	}()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return simResult, nil
-		case err := <-chErr:
-			return nil, err
-		case simRun := <-ch:
-			// Prepare metrics
-			initialState := simRun[0]
-			finalState := simRun[1]
-			attackerWon := 0
-			if finalState.AttackerUnits >= ENGAGE_RULE_MIN_ATTACK {
-				// If the attacker is left with less units than what's needed to
-				// attack it means they ran out of attacks
-				attackerWon = 1
-			}
-
-			// Make sure object is mapped
-			if sas, ok := simResult[initialState.AttackerUnits]; !ok {
-				simResult[initialState.AttackerUnits] = map[int]SimulationResult{}
-				if _, ok := sas[initialState.DefenderUnits]; !ok {
-					simResult[initialState.AttackerUnits][initialState.DefenderUnits] = SimulationResult{
-						NRuns:                  0,
-						NAttackerWon:           0,
-						TotalAttackerUnitsLeft: 0,
-					}
-				}
-			}
-
-			simBatch := simResult[initialState.AttackerUnits][initialState.DefenderUnits]
-			simResult[initialState.AttackerUnits][initialState.DefenderUnits] = SimulationResult{
-				NRuns:                  simBatch.NRuns + 1,
-				NAttackerWon:           simBatch.NAttackerWon + attackerWon,
-				TotalAttackerUnitsLeft: simBatch.TotalAttackerUnitsLeft + finalState.AttackerUnits,
-			}
+		recorder.RecordEnding(ending)
+	}
+	return state, lastAttackDices, nil
+}
 
-			if finalState.AttackerUnits < 0 {
-				fmt.Printf("Whats going on %v -> %v", initialState, finalState)
-			}
+// Makes sure a side throws at least one dice and no more than the rules allow
+func validateDices(rules Rules, side Side, state BattleState, dices Dices) error {
+	maxDices, err := getMaxDices(rules, side, state)
+	if err != nil {
+		return err
+	}
+	if dices.Count() < 1 || dices.Count() > maxDices {
+		return &InvalidDiceCountError{Side: side, State: state, Count: dices.Count(), Max: maxDices}
+	}
+	return nil
+}
 
-			simsCount++
-			if simsCount == nRuns*(nUnitsSweep-(ENGAGE_RULE_MIN_ATTACK-1))*nUnitsSweep {
-				return simResult, nil
+// Rolls the dices of a side with the dice the rules give it. Dices that
+// aren't a DiceRoller can only throw the fair six sided dice.
+func rollDices(rules Rules, side Side, state BattleState, dices Dices) ([]int, error) {
+	dice := rules.Dice(side)
+	roller, ok := dices.(DiceRoller)
+	if !ok && !dice.equal(sixSidedDice) {
+		return nil, fmt.Errorf("%w: %v dices cannot throw the %d sided dice of the rules", ErrInvalidDice, side, dice.Faces())
+	}
+	if !ok {
+		roll := dices.Roll()
+		for _, value := range roll {
+			if value < 1 || value > dice.Faces() {
+				return nil, fmt.Errorf("%w: %v threw %d at %v but its dice has faces from 1 to %d", ErrInvalidRoll, side, value, state, dice.Faces())
 			}
 		}
+		return roll, nil
+	}
+	roll, err := roller.RollDice(dice)
+	if err != nil {
+		return nil, fmt.Errorf("%v at %v: %w", side, state, err)
 	}
+	return roll, nil
 }
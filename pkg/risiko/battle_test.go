@@ -1,9 +1,9 @@
 package risiko
 
 import (
-	"context"
+	"errors"
 	"fmt"
-	"math/rand"
+	"math/rand/v2"
 	"sync"
 	"testing"
 )
@@ -23,9 +23,9 @@ func createTestSingleSidedDicesGen(number int) func(int) (Dices, error) {
 // deterministic random dices gen
 func testDeterministicDicesGen(count int) (Dices, error) {
 	if count < 0 {
-		return nil, fmt.Errorf("Dices cannot be a negative number")
+		return nil, fmt.Errorf("%w: cannot throw %d dices", ErrInvalidDiceCount, count)
 	}
-	return &fairDices{nDices: count, random: rand.New(rand.NewSource(1))}, nil
+	return &randomDices{nDices: count, random: rand.New(rand.NewPCG(1, 1))}, nil
 }
 
 func TestBattleEssentials(t *testing.T) {
@@ -38,13 +38,15 @@ func TestBattleEssentials(t *testing.T) {
 	}{
 		{
 			name: "attacker cheater",
-			attacker: func() EngageStrategy {
+			attacker: func(rules Rules) EngageStrategy {
 				return &maxAttackers{
+					rules:    rules,
 					genDices: createTestSingleSidedDicesGen(6),
 				}
 			},
-			defender: func() EngageStrategy {
+			defender: func(rules Rules) EngageStrategy {
 				return &maxAttackers{
+					rules:    rules,
 					genDices: createTestSingleSidedDicesGen(3),
 				}
 			},
@@ -59,13 +61,15 @@ func TestBattleEssentials(t *testing.T) {
 		},
 		{
 			name: "defender cheater",
-			attacker: func() EngageStrategy {
+			attacker: func(rules Rules) EngageStrategy {
 				return &maxAttackers{
+					rules:    rules,
 					genDices: createTestSingleSidedDicesGen(1),
 				}
 			},
-			defender: func() EngageStrategy {
+			defender: func(rules Rules) EngageStrategy {
 				return &maxDefenders{
+					rules:    rules,
 					genDices: createTestSingleSidedDicesGen(1),
 				}
 			},
@@ -82,7 +86,7 @@ func TestBattleEssentials(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			got, err := Battle(tc.state, tc.attacker, tc.defender)
+			got, err := Battle(RisiKoRules, tc.state, tc.attacker, tc.defender)
 			if err != nil {
 				t.Errorf("Unexpected error")
 			}
@@ -108,7 +112,7 @@ func TestBattleResults(t *testing.T) {
 		state    BattleState
 	}
 	testCases := []TestCase{}
-	for a := ENGAGE_RULE_MIN_ATTACK; a <= maxAttackers; a++ {
+	for a := RisiKoRules.MinAttackUnits; a <= maxAttackers; a++ {
 		for d := 1; d <= maxDefenders; d++ {
 			for i := 0; i < nBattles; i++ {
 				testCases = append(testCases, TestCase{
@@ -130,7 +134,7 @@ func TestBattleResults(t *testing.T) {
 		go func(tc TestCase) {
 			defer wg.Done()
 			t.Run(tc.name, func(t *testing.T) {
-				got, err := Battle(tc.state, tc.attacker, tc.defender)
+				got, err := Battle(RisiKoRules, tc.state, tc.attacker, tc.defender)
 				if err != nil {
 					t.Errorf("Unexpected error")
 				}
@@ -153,48 +157,119 @@ func TestBattleResults(t *testing.T) {
 	wg.Wait()
 }
 
-func TestSimulate(t *testing.T) {
-	rand.New(rand.NewSource(1))
-	ctx := context.Background()
+func TestBattleRetreat(t *testing.T) {
+	// Attacker loses every engagement and retreats once below 7 units
+	attacker := NewRetreatAttackersStrategy(createTestSingleSidedDicesGen(1), RetreatThresholds{MinUnits: 7})
+	defender := NewMaxDefendersStrategy(createTestSingleSidedDicesGen(6))
+	got, err := Battle(RisiKoRules, BattleState{AttackerUnits: 10, DefenderUnits: 3}, attacker, defender)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if want := (BattleState{AttackerUnits: 4, DefenderUnits: 3}); got != want {
+		t.Errorf("Unexpected final state. Want %v but got %v", want, got)
+	}
+	if got.AttackerWon() {
+		t.Errorf("Expected a retreat not to count as a win")
+	}
+}
+
+func TestBattleInvalidDices(t *testing.T) {
 	testCases := []struct {
-		name        string
-		attacker    BattleStrategy
-		defender    BattleStrategy
-		nUnitsSweep int
-		nRuns       int
+		name     string
+		attacker BattleStrategy
+		defender BattleStrategy
+		wantSide Side
 	}{
 		{
-			name:        "normal cheater",
-			attacker:    NewMaxAttackersStrategy(createTestSingleSidedDicesGen(6)),
-			defender:    NewMaxDefendersStrategy(createTestSingleSidedDicesGen(6)),
-			nUnitsSweep: 3,
-			nRuns:       5,
+			name:     "attacker too many",
+			attacker: NewPolicyAttackersStrategy(FairDicesGen, func(BattleState, int) int { return 4 }),
+			defender: NewMaxDefendersStrategy(FairDicesGen),
+			wantSide: AttackerSide,
+		},
+		{
+			name:     "defender none",
+			attacker: NewMaxAttackersStrategy(FairDicesGen),
+			defender: NewPolicyDefendersStrategy(FairDicesGen, func(BattleState, int) int { return 0 }),
+			wantSide: DefenderSide,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			result, err := Simulate(ctx, tc.nRuns, tc.nUnitsSweep, tc.attacker, tc.defender)
-			if err != nil {
-				t.Errorf("Unexpected error %v", err)
+			_, err := Battle(RisiKoRules, BattleState{AttackerUnits: 10, DefenderUnits: 10}, tc.attacker, tc.defender)
+			var diceErr *InvalidDiceCountError
+			if !errors.As(err, &diceErr) {
+				t.Fatalf("Expected invalid dice count error but got %v", err)
+			}
+			if diceErr.Side != tc.wantSide {
+				t.Errorf("Expected the %v to be rejected but got the %v", tc.wantSide, diceErr.Side)
 			}
+		})
+	}
+}
 
-			for a := ENGAGE_RULE_MIN_ATTACK; a <= tc.nUnitsSweep; a++ {
-				for d := 1; d <= tc.nUnitsSweep; d++ {
-					if _, ok := result[a]; !ok {
-						t.Errorf("unexpected empty object for %d attackers", a)
-					} else if got, ok := result[a][d]; !ok {
-						t.Errorf("unexpected empty object for %d defenders", d)
-					} else if got.NRuns != tc.nRuns {
-						t.Errorf("unexpected n of runs for %d attackers and %d defenders. Got %d and wanted %d", a, d, got.NRuns, tc.nRuns)
-					}
-					if result[a][d].NAttackerWon < 0 {
-						t.Errorf("impossible that attackers won is less than 0, but got %d", result[a][d].NAttackerWon)
-					}
-					if result[a][d].TotalAttackerUnitsLeft < 0 {
-						t.Errorf("impossible that total attacker units left are less than 0, but got %d", result[a][d].TotalAttackerUnitsLeft)
-					}
+func TestBattleRulesDice(t *testing.T) {
+	eightSided := RisiKoRules
+	eightSided.AttackDice = mustNewFairDice(8)
+	state := BattleState{AttackerUnits: 4, DefenderUnits: 3}
+	testCases := []struct {
+		name     string
+		rules    Rules
+		attacker BattleStrategy
+		want     BattleState
+		wantErr  error
+	}{
+		{
+			name:     "recorded eights on eight sided dices",
+			rules:    eightSided,
+			attacker: NewMaxAttackersStrategy(NewReplayDicesGen([][]int{{8, 8, 7}})),
+			want:     BattleState{AttackerUnits: 4, DefenderUnits: 0},
+		},
+		{
+			name:     "recorded nine on eight sided dices",
+			rules:    eightSided,
+			attacker: NewMaxAttackersStrategy(NewReplayDicesGen([][]int{{9, 8, 7}})),
+			wantErr:  ErrInvalidRoll,
+		},
+		{
+			name:     "dices unable to throw eight sided dices",
+			rules:    eightSided,
+			attacker: NewMaxAttackersStrategy(createTestSingleSidedDicesGen(8)),
+			wantErr:  ErrInvalidDice,
+		},
+		{
+			name:     "eights on six sided dices",
+			rules:    RisiKoRules,
+			attacker: NewMaxAttackersStrategy(createTestSingleSidedDicesGen(8)),
+			wantErr:  ErrInvalidRoll,
+		},
+		{
+			name:     "eight sided generator for six sided rules",
+			rules:    RisiKoRules,
+			attacker: NewMaxAttackersStrategy(NewCustomDicesGen(nil, mustNewFairDice(8))),
+			wantErr:  ErrInvalidDice,
+		},
+		{
+			name:     "same dice as the rules",
+			rules:    eightSided,
+			attacker: NewMaxAttackersStrategy(NewCustomDicesGen(nil, mustNewFairDice(8))),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Battle(tc.rules, state, tc.attacker, NewMaxDefendersStrategy(createTestSingleSidedDicesGen(6)))
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("Expected %v but got %v", tc.wantErr, err)
 				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			if tc.want != (BattleState{}) && got != tc.want {
+				t.Errorf("Expected %v but got %v", tc.want, got)
 			}
 		})
 	}
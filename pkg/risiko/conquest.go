@@ -0,0 +1,63 @@
+package risiko
+
+import (
+	"fmt"
+)
+
+// Armies on the two territories after the attacker conquered the defender's
+type Conquest struct {
+	// Units left on the attacking territory
+	UnitsStayed int
+	// Units moved into the conquered territory
+	UnitsMoved int
+}
+
+// Decides how many units to move into the conquered territory on top of
+// minMove, the minimum mandated by the rules. The returned extra units must
+// not take the move past maxMove.
+type ConquestStrategy = func(final BattleState, minMove int, maxMove int) int
+
+// Only moves the units required by the rules
+func NewMinimumConquestStrategy() ConquestStrategy {
+	return func(final BattleState, minMove int, maxMove int) int {
+		return 0
+	}
+}
+
+// Moves every unit that is allowed to leave the attacking territory
+func NewMaximumConquestStrategy() ConquestStrategy {
+	return func(final BattleState, minMove int, maxMove int) int {
+		return maxMove - minMove
+	}
+}
+
+// Tries to keep the given units on the attacking territory, moving the rest
+func NewKeepBehindConquestStrategy(units int) ConquestStrategy {
+	return func(final BattleState, minMove int, maxMove int) int {
+		return max(0, min(maxMove, final.AttackerUnits-units)-minMove)
+	}
+}
+
+// Moves the attacker armies into the conquered territory. The attacker must
+// move at least as many units as the dices it threw last and cannot move the
+// units that must stay behind, so it fails when only those are left.
+func Conquer(rules Rules, final BattleState, lastAttackDices int, strategy ConquestStrategy) (Conquest, error) {
+	if !final.AttackerWon() {
+		return Conquest{}, fmt.Errorf("%w: cannot conquer a territory with %d defenders left", ErrInvalidConquest, final.DefenderUnits)
+	}
+	maxMove := final.AttackerUnits - rules.UnitsStayBehind
+	if maxMove < 1 {
+		// E.g. when both sides lose on ties and the last defender took the
+		// last attacker able to move with it
+		return Conquest{}, fmt.Errorf("%w: no units can move with %d attackers left and %d staying behind", ErrInvalidConquest, final.AttackerUnits, rules.UnitsStayBehind)
+	}
+	minMove := min(lastAttackDices, maxMove)
+	extra := strategy(final, minMove, maxMove)
+	if extra < 0 || minMove+extra > maxMove {
+		return Conquest{}, fmt.Errorf("%w: cannot move %d extra units, must be between 0 and %d", ErrInvalidConquest, extra, maxMove-minMove)
+	}
+	return Conquest{
+		UnitsStayed: final.AttackerUnits - minMove - extra,
+		UnitsMoved:  minMove + extra,
+	}, nil
+}
@@ -0,0 +1,154 @@
+package risiko
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestConquer(t *testing.T) {
+	final := BattleState{AttackerUnits: 8, DefenderUnits: 0}
+	testCases := []struct {
+		name            string
+		final           BattleState
+		lastAttackDices int
+		strategy        ConquestStrategy
+		want            Conquest
+		wantErr         bool
+	}{
+		{
+			name:            "minimum",
+			final:           final,
+			lastAttackDices: 3,
+			strategy:        NewMinimumConquestStrategy(),
+			want:            Conquest{UnitsStayed: 5, UnitsMoved: 3},
+		},
+		{
+			name:            "maximum",
+			final:           final,
+			lastAttackDices: 2,
+			strategy:        NewMaximumConquestStrategy(),
+			want:            Conquest{UnitsStayed: 1, UnitsMoved: 7},
+		},
+		{
+			name:            "keep behind",
+			final:           final,
+			lastAttackDices: 3,
+			strategy:        NewKeepBehindConquestStrategy(4),
+			want:            Conquest{UnitsStayed: 4, UnitsMoved: 4},
+		},
+		{
+			name:            "keep behind more than allowed",
+			final:           final,
+			lastAttackDices: 3,
+			strategy:        NewKeepBehindConquestStrategy(7),
+			want:            Conquest{UnitsStayed: 5, UnitsMoved: 3},
+		},
+		{
+			name:            "fewer units than dices",
+			final:           BattleState{AttackerUnits: 2, DefenderUnits: 0},
+			lastAttackDices: 3,
+			strategy:        NewMinimumConquestStrategy(),
+			want:            Conquest{UnitsStayed: 1, UnitsMoved: 1},
+		},
+		{
+			name:            "too many extra units",
+			final:           final,
+			lastAttackDices: 3,
+			strategy:        func(BattleState, int, int) int { return 5 },
+			wantErr:         true,
+		},
+		{
+			name:            "only units staying behind left",
+			final:           BattleState{AttackerUnits: 1, DefenderUnits: 0},
+			lastAttackDices: 2,
+			strategy:        NewMinimumConquestStrategy(),
+			wantErr:         true,
+		},
+		{
+			name:            "defenders left",
+			final:           BattleState{AttackerUnits: 1, DefenderUnits: 3},
+			lastAttackDices: 1,
+			strategy:        NewMinimumConquestStrategy(),
+			wantErr:         true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Conquer(RisiKoRules, tc.final, tc.lastAttackDices, tc.strategy)
+			if tc.wantErr {
+				if !errors.Is(err, ErrInvalidConquest) {
+					t.Errorf("Expected invalid conquest but got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Expected %+v but got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestBattleAndConquer(t *testing.T) {
+	attacker := NewMaxAttackersStrategy(createTestSingleSidedDicesGen(6))
+	defender := NewMaxDefendersStrategy(createTestSingleSidedDicesGen(1))
+	got, err := BattleAndConquer(RisiKoRules, BattleState{AttackerUnits: 10, DefenderUnits: 2}, attacker, defender, NewMinimumConquestStrategy())
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if got.Final != (BattleState{AttackerUnits: 10, DefenderUnits: 0}) {
+		t.Errorf("Unexpected final state %v", got.Final)
+	}
+	if got.LastAttackDices != 3 {
+		t.Errorf("Expected the attacker to throw 3 dices last but got %d", got.LastAttackDices)
+	}
+	if got.Conquest == nil || *got.Conquest != (Conquest{UnitsStayed: 7, UnitsMoved: 3}) {
+		t.Errorf("Unexpected conquest %+v", got.Conquest)
+	}
+
+	// Attacker wins with only the unit staying behind left
+	bothLose := RisiKoRules
+	bothLose.Ties = BothLoseOnTies
+	sixes := NewMaxAttackersStrategy(createTestSingleSidedDicesGen(6))
+	got, err = BattleAndConquer(bothLose, BattleState{AttackerUnits: 2, DefenderUnits: 1}, sixes, NewMaxDefendersStrategy(createTestSingleSidedDicesGen(6)), NewMinimumConquestStrategy())
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if got.Final != (BattleState{AttackerUnits: 1, DefenderUnits: 0}) || got.Conquest != nil {
+		t.Errorf("Expected a failed conquest at {1 0} but got %v and %+v", got.Final, got.Conquest)
+	}
+
+	// Attacker loses
+	attacker = NewMaxAttackersStrategy(createTestSingleSidedDicesGen(1))
+	defender = NewMaxDefendersStrategy(createTestSingleSidedDicesGen(6))
+	got, err = BattleAndConquer(RisiKoRules, BattleState{AttackerUnits: 10, DefenderUnits: 2}, attacker, defender, NewMinimumConquestStrategy())
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if got.Conquest != nil {
+		t.Errorf("Expected no conquest but got %+v", got.Conquest)
+	}
+}
+
+func TestSimulateConquest(t *testing.T) {
+	nUnitsSweep := 6
+	result, err := Simulate(context.Background(), 200, nUnitsSweep, NewMaxAttackersStrategy(FairDicesGen), NewMaxDefendersStrategy(FairDicesGen), WithConquest(NewMaximumConquestStrategy()))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	for a := RisiKoRules.MinAttackUnits; a <= nUnitsSweep; a++ {
+		for d := 1; d <= nUnitsSweep; d++ {
+			got := result[a][d]
+			// Moving everything leaves exactly the units staying behind
+			want := got.AttackerUnitsLeftOnWin() - float64(RisiKoRules.UnitsStayBehind)
+			if got.NAttackerWon > 0 && math.Abs(got.UnitsMovedOnWin()-want) > 1e-9 {
+				t.Errorf("Expected %f units moved for %d attackers and %d defenders but got %f", want, a, d, got.UnitsMovedOnWin())
+			}
+		}
+	}
+}
@@ -1,8 +1,17 @@
 package risiko
 
 import (
+	"bufio"
+	crand "crypto/rand"
+	"encoding/binary"
+	"encoding/json"
 	"fmt"
-	"math/rand"
+	"io"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
 )
 
 type Dices interface {
@@ -10,30 +19,312 @@ type Dices interface {
 	Roll() []int
 }
 
-type fairDices struct {
+// Dices able to throw a given dice. Battles throw them with the dice the rules
+// give their side, and only accept other dices when that is the fair six
+// sided one.
+type DiceRoller interface {
+	Dices
+	// Rolls like Roll with dice, failing with ErrInvalidDice when the dices
+	// cannot throw it or with ErrInvalidRoll when a throw isn't a face of it
+	RollDice(dice *Dice) ([]int, error)
+}
+
+type randomDices struct {
+	// Throws from the global source when nil
 	random *rand.Rand
+	// Fair six sided dices when nil
+	dice   *Dice
 	nDices int
 }
 
 type DicesGenerator = func(int) (Dices, error)
 
+// Throws from the global math/rand/v2 source, which is safe for concurrent use
 func FairDicesGen(count int) (Dices, error) {
 	if count < 0 {
-		return nil, fmt.Errorf("Dices cannot be a negative number")
+		return nil, fmt.Errorf("%w: cannot throw %d dices", ErrInvalidDiceCount, count)
+	}
+	return &randomDices{nDices: count}, nil
+}
+
+// Returns a generator whose dices all throw from src, e.g. rand.NewPCG,
+// rand.NewChaCha8 or CryptoSource. Most sources are not safe for concurrent
+// use, in that case the generator and its dices must stay on one goroutine.
+func NewDicesGen(src rand.Source) DicesGenerator {
+	return NewCustomDicesGen(src, nil)
+}
+
+// Returns a generator like NewDicesGen whose dices all have the faces and
+// weights of dice, fair six sided dices when nil. A nil src throws from the
+// global source, which is safe for concurrent use.
+func NewCustomDicesGen(src rand.Source, dice *Dice) DicesGenerator {
+	var random *rand.Rand
+	if src != nil {
+		random = rand.New(src)
+	}
+	return func(count int) (Dices, error) {
+		if count < 0 {
+			return nil, fmt.Errorf("%w: cannot throw %d dices", ErrInvalidDiceCount, count)
+		}
+		return &randomDices{nDices: count, random: random, dice: dice}, nil
 	}
-	return &fairDices{nDices: count, random: rand.New(rand.NewSource(int64(rand.Int())))}, nil
+}
+
+// Returns a generator whose dices all throw from the same random stream,
+// identified by seed and stream. Generators with the same seed and stream
+// throw the same sequence, different streams are independent. The generator
+// and its dices are not safe for concurrent use.
+func NewSeededDicesGen(seed uint64, stream uint64) DicesGenerator {
+	src := &rand.PCG{}
+	seedStream(src, seed, stream)
+	return NewDicesGen(src)
+}
+
+// Moves src to the start of the stream identified by seed and stream
+func seedStream(src *rand.PCG, seed uint64, stream uint64) {
+	src.Seed(splitMix64(seed), splitMix64(stream))
+}
+
+// Scrambles x so that close inputs give unrelated seeds
+func splitMix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// Source reading from crypto/rand, for games where throws must not be
+// predictable. Safe for concurrent use.
+type CryptoSource struct{}
+
+func (CryptoSource) Uint64() uint64 {
+	var b [8]byte
+	// Zeroed bytes would make every throw predictable, so a source that can't
+	// be read is fatal
+	if _, err := crand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("reading crypto/rand: %v", err))
+	}
+	return binary.LittleEndian.Uint64(b[:])
 }
 
 // Returns Count() dices throws sorted in descending order
-func (f *fairDices) Roll() []int {
-	res := []int{}
-	for i := 0; i < f.nDices; i++ {
-		res = append(res, f.random.Intn(6)+1)
+func (f *randomDices) Roll() []int {
+	return f.roll(f.dice)
+}
+
+// Throws dice, unless the dices were built with a different one
+func (f *randomDices) RollDice(dice *Dice) ([]int, error) {
+	if f.dice != nil && !f.dice.equal(dice) {
+		return nil, fmt.Errorf("%w: dices with %d faces cannot throw a dice with %d", ErrInvalidDice, f.dice.Faces(), dice.Faces())
+	}
+	if dice.equal(sixSidedDice) {
+		return f.roll(f.dice), nil
+	}
+	return f.roll(dice), nil
+}
+
+// Throws dice, fair six sided dices when nil
+func (f *randomDices) roll(dice *Dice) []int {
+	res := make([]int, f.nDices)
+	for i := range res {
+		if dice != nil {
+			res[i] = dice.roll(f.random)
+		} else if f.random == nil {
+			res[i] = rand.IntN(6) + 1
+		} else {
+			res[i] = f.random.IntN(6) + 1
+		}
 	}
 	return res
 }
 
 // How many dices?
-func (f *fairDices) Count() int {
+func (f *randomDices) Count() int {
 	return f.nDices
 }
+
+///////////////////////////////////////////////////////////////////////////////
+// Dice -> Faces numbered from 1 and how likely each one comes out, for house
+// rules with bigger dices or to experiment with loaded ones
+///////////////////////////////////////////////////////////////////////////////
+
+// A dice is immutable once created, so it's safe for concurrent use
+type Dice struct {
+	probabilities []float64
+	// Probability of rolling up to each face, used to roll
+	cumulative []float64
+}
+
+// Fair six sided dice thrown when the rules don't say otherwise
+var sixSidedDice = mustNewFairDice(6)
+
+// Returns a dice whose face i+1 comes out with probability proportional to
+// weights[i]
+func NewDice(weights ...float64) (*Dice, error) {
+	if len(weights) == 0 {
+		return nil, fmt.Errorf("%w: a dice needs at least one face", ErrInvalidDice)
+	}
+	total := 0.0
+	for i, weight := range weights {
+		if weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return nil, fmt.Errorf("%w: face %d has weight %f", ErrInvalidDice, i+1, weight)
+		}
+		total += weight
+	}
+	if total <= 0 || math.IsInf(total, 0) {
+		return nil, fmt.Errorf("%w: weights must add up to a positive number, got %f", ErrInvalidDice, total)
+	}
+	d := &Dice{}
+	cumulative := 0.0
+	for _, weight := range weights {
+		cumulative += weight / total
+		d.probabilities = append(d.probabilities, weight/total)
+		d.cumulative = append(d.cumulative, cumulative)
+	}
+	return d, nil
+}
+
+// Returns a dice whose faces all come out with the same probability
+func NewFairDice(faces int) (*Dice, error) {
+	if faces < 1 {
+		return nil, fmt.Errorf("%w: a dice needs at least one face, got %d", ErrInvalidDice, faces)
+	}
+	weights := make([]float64, faces)
+	for i := range weights {
+		weights[i] = 1
+	}
+	return NewDice(weights...)
+}
+
+func mustNewFairDice(faces int) *Dice {
+	d, err := NewFairDice(faces)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func (d *Dice) Faces() int {
+	return len(d.probabilities)
+}
+
+// Whether both dices have the same faces with the same probabilities
+func (d *Dice) equal(other *Dice) bool {
+	return d == other || slices.Equal(d.probabilities, other.probabilities)
+}
+
+// Probability of rolling face, 0 when the dice doesn't have it
+func (d *Dice) Probability(face int) float64 {
+	if face < 1 || face > d.Faces() {
+		return 0
+	}
+	return d.probabilities[face-1]
+}
+
+// Encodes the probability of each face
+func (d *Dice) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.probabilities)
+}
+
+func (d *Dice) UnmarshalJSON(data []byte) error {
+	var probabilities []float64
+	if err := json.Unmarshal(data, &probabilities); err != nil {
+		return err
+	}
+	dice, err := NewDice(probabilities...)
+	if err != nil {
+		return err
+	}
+	*d = *dice
+	return nil
+}
+
+// Rolls the dice with random, or the global source when nil
+func (d *Dice) roll(random *rand.Rand) int {
+	var u float64
+	if random == nil {
+		u = rand.Float64()
+	} else {
+		u = random.Float64()
+	}
+	face, _ := slices.BinarySearch(d.cumulative, u)
+	// Rounding can leave the last cumulative probability just below 1
+	return min(face+1, d.Faces())
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// Recorded dices -> Throws decided outside of the program, e.g. real dices
+// rolled at the table or a replayed transcript
+///////////////////////////////////////////////////////////////////////////////
+
+// Dices always throwing the same values
+type recordedDices struct {
+	throws []int
+}
+
+func (r *recordedDices) Count() int {
+	return len(r.throws)
+}
+
+// Returns the recorded throws sorted in descending order
+func (r *recordedDices) Roll() []int {
+	throws := slices.Clone(r.throws)
+	slices.Sort(throws)
+	slices.Reverse(throws)
+	return throws
+}
+
+// Returns the recorded throws like Roll when they are all faces of dice
+func (r *recordedDices) RollDice(dice *Dice) ([]int, error) {
+	for _, throw := range r.throws {
+		if throw < 1 || throw > dice.Faces() {
+			return nil, fmt.Errorf("%w: threw %d but the dice has faces from 1 to %d", ErrInvalidRoll, throw, dice.Faces())
+		}
+	}
+	return r.Roll(), nil
+}
+
+// Returns a generator reading a roll per line from r, e.g. "6 4 1". Blank
+// lines are skipped. It fails with ErrInvalidRoll when a line isn't a roll of
+// the asked number of dices, and with io.EOF when r has no rolls left. It is
+// not safe for concurrent use.
+func NewReaderDicesGen(r io.Reader) DicesGenerator {
+	scanner := bufio.NewScanner(r)
+	return func(count int) (Dices, error) {
+		if count < 0 {
+			return nil, fmt.Errorf("%w: cannot throw %d dices", ErrInvalidDiceCount, count)
+		}
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			throws, err := parseRoll(line)
+			if err != nil {
+				return nil, err
+			}
+			if len(throws) != count {
+				return nil, fmt.Errorf("%w: %q has %d dices, expected %d", ErrInvalidRoll, line, len(throws), count)
+			}
+			return &recordedDices{throws: throws}, nil
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+}
+
+// Parses space separated dice values of at least 1. Battles check them
+// against the faces of the dice in the rules.
+func parseRoll(line string) ([]int, error) {
+	throws := []int{}
+	for _, field := range strings.Fields(line) {
+		value, err := strconv.Atoi(field)
+		if err != nil || value < 1 {
+			return nil, fmt.Errorf("%w: %q is not a dice value", ErrInvalidRoll, field)
+		}
+		throws = append(throws, value)
+	}
+	return throws, nil
+}
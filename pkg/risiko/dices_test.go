@@ -1,7 +1,14 @@
 package risiko
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	v1 "math/rand"
+	"math/rand/v2"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -34,3 +41,188 @@ func TestFairDices_Roll(t *testing.T) {
 		})
 	}
 }
+
+func TestSeededDicesGen(t *testing.T) {
+	throwAll := func(gen DicesGenerator) []int {
+		throws := []int{}
+		for i := 1; i <= 10; i++ {
+			dices, err := gen(i % 4)
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			throws = append(throws, dices.Roll()...)
+		}
+		return throws
+	}
+
+	first := throwAll(NewSeededDicesGen(42, 7))
+	if !slices.Equal(first, throwAll(NewSeededDicesGen(42, 7))) {
+		t.Errorf("Expected same seed and stream to throw the same dices")
+	}
+	if slices.Equal(first, throwAll(NewSeededDicesGen(42, 8))) {
+		t.Errorf("Expected different streams to throw different dices")
+	}
+	if slices.Equal(first, throwAll(NewSeededDicesGen(43, 7))) {
+		t.Errorf("Expected different seeds to throw different dices")
+	}
+	if _, err := NewSeededDicesGen(42, 7)(-1); err == nil {
+		t.Errorf("Expected new dices to fail")
+	}
+}
+
+func TestNewDicesGen(t *testing.T) {
+	sources := map[string]rand.Source{
+		"pcg":     rand.NewPCG(1, 2),
+		"chacha8": rand.NewChaCha8([32]byte{}),
+		"crypto":  CryptoSource{},
+	}
+	for name, src := range sources {
+		t.Run(name, func(t *testing.T) {
+			gen := NewDicesGen(src)
+			for range 100 {
+				dices, err := gen(3)
+				if err != nil {
+					t.Fatalf("Unexpected error %v", err)
+				}
+				for _, throw := range dices.Roll() {
+					if throw < 1 || throw > 6 {
+						t.Errorf("Unexpected throw %d", throw)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestNewDice(t *testing.T) {
+	testCases := []struct {
+		name    string
+		weights []float64
+		want    []float64
+	}{
+		{name: "fair", weights: []float64{1, 1, 1, 1}, want: []float64{0.25, 0.25, 0.25, 0.25}},
+		{name: "loaded", weights: []float64{1, 0, 3}, want: []float64{0.25, 0, 0.75}},
+		{name: "no faces"},
+		{name: "negative weight", weights: []float64{1, -1}},
+		{name: "zero weights", weights: []float64{0, 0}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dice, err := NewDice(tc.weights...)
+			if tc.want == nil {
+				if !errors.Is(err, ErrInvalidDice) {
+					t.Errorf("Expected invalid dice but got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			if dice.Faces() != len(tc.want) {
+				t.Errorf("Expected %d faces but got %d", len(tc.want), dice.Faces())
+			}
+			for i, p := range tc.want {
+				if got := dice.Probability(i + 1); math.Abs(got-p) > 1e-12 {
+					t.Errorf("Expected face %d probability %f but got %f", i+1, p, got)
+				}
+			}
+		})
+	}
+	if _, err := NewFairDice(0); !errors.Is(err, ErrInvalidDice) {
+		t.Errorf("Expected dice without faces to fail but got %v", err)
+	}
+}
+
+func TestCustomDicesGen(t *testing.T) {
+	dice, err := NewDice(1, 0, 0, 0, 0, 0, 0, 3)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	gen := NewCustomDicesGen(rand.NewPCG(1, 2), dice)
+	nThrows := 0
+	counts := map[int]int{}
+	for range 10000 {
+		dices, err := gen(3)
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		for _, throw := range dices.Roll() {
+			counts[throw]++
+			nThrows++
+		}
+	}
+	if len(counts) != 2 {
+		t.Errorf("Expected only faces 1 and 8 but got %v", counts)
+	}
+	if got := float64(counts[8]) / float64(nThrows); math.Abs(got-0.75) > 0.02 {
+		t.Errorf("Expected face 8 three times out of four but got %f", got)
+	}
+}
+
+// Compare allocations with go test -bench DicesGen -benchmem
+func BenchmarkDicesGen(b *testing.B) {
+	generators := []struct {
+		name string
+		gen  DicesGenerator
+	}{
+		{
+			name: "v1 source per call",
+			gen: func(count int) (Dices, error) {
+				random := v1.New(v1.NewSource(v1.Int63()))
+				return getLoadedDices([]int{random.Intn(6) + 1, random.Intn(6) + 1, random.Intn(6) + 1}[:count]), nil
+			},
+		},
+		{name: "global", gen: FairDicesGen},
+		{name: "pcg", gen: NewDicesGen(rand.NewPCG(1, 2))},
+		{name: "chacha8", gen: NewDicesGen(rand.NewChaCha8([32]byte{}))},
+		{name: "crypto", gen: NewDicesGen(CryptoSource{})},
+	}
+	for _, g := range generators {
+		b.Run(g.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				dices, err := g.gen(3)
+				if err != nil {
+					b.Fatal(err)
+				}
+				dices.Roll()
+			}
+		})
+	}
+}
+
+func TestReaderDicesGen(t *testing.T) {
+	gen := NewReaderDicesGen(strings.NewReader("6 4 1\n\n  3 5\n1 2\n8\n0\nsix\n"))
+	testCases := []struct {
+		count   int
+		want    []int
+		wantErr error
+	}{
+		{count: 3, want: []int{6, 4, 1}},
+		{count: 2, want: []int{5, 3}},
+		{count: 3, wantErr: ErrInvalidRoll},
+		// Faces are checked against the rules by the battle
+		{count: 1, want: []int{8}},
+		{count: 1, wantErr: ErrInvalidRoll},
+		{count: 1, wantErr: ErrInvalidRoll},
+		{count: 1, wantErr: io.EOF},
+	}
+	for i, tc := range testCases {
+		dices, err := gen(tc.count)
+		if tc.wantErr != nil {
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("Roll %d: expected %v but got %v", i, tc.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Roll %d: unexpected error %v", i, err)
+		}
+		if dices.Count() != tc.count {
+			t.Errorf("Roll %d: expected %d dices but got %d", i, tc.count, dices.Count())
+		}
+		if got := dices.Roll(); !slices.Equal(got, tc.want) {
+			t.Errorf("Roll %d: expected %v but got %v", i, tc.want, got)
+		}
+	}
+}
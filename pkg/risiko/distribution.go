@@ -0,0 +1,117 @@
+package risiko
+
+import (
+	"maps"
+	"slices"
+)
+
+// Probability of each final battle state
+type Distribution map[BattleState]float64
+
+// Probability that the attacker conquers the territory
+func (d Distribution) WinProbability() float64 {
+	p := 0.0
+	for state, prob := range d {
+		if state.AttackerWon() {
+			p += prob
+		}
+	}
+	return p
+}
+
+// Expected attacker units at the end of the battle
+func (d Distribution) ExpectedAttackerUnitsLeft() float64 {
+	units := 0.0
+	for state, prob := range d {
+		units += prob * float64(state.AttackerUnits)
+	}
+	return units
+}
+
+// Expected attacker units at the end of the battle given the attacker won
+func (d Distribution) AttackerUnitsLeftOnWin() float64 {
+	units := 0.0
+	for state, prob := range d {
+		if state.AttackerWon() {
+			units += prob * float64(state.AttackerUnits)
+		}
+	}
+	return units / d.WinProbability()
+}
+
+// Expected defender units at the end of the battle
+func (d Distribution) ExpectedDefenderUnitsLeft() float64 {
+	units := 0.0
+	for state, prob := range d {
+		units += prob * float64(state.DefenderUnits)
+	}
+	return units
+}
+
+// Expected defender units lost by a battle that started at initial
+func (d Distribution) ExpectedDefenderLosses(initial BattleState) float64 {
+	return float64(initial.DefenderUnits) - d.ExpectedDefenderUnitsLeft()
+}
+
+// Expected defender units at the end of the battle given the attacker lost
+func (d Distribution) DefenderUnitsLeftOnLoss() float64 {
+	units := 0.0
+	for state, prob := range d {
+		if !state.AttackerWon() {
+			units += prob * float64(state.DefenderUnits)
+		}
+	}
+	return units / (1 - d.WinProbability())
+}
+
+// Probability that the attacker ends the battle with at most units
+func (d Distribution) AttackerUnitsCDF(units int) float64 {
+	return d.cdf(units, func(s BattleState) int { return s.AttackerUnits })
+}
+
+// Probability that the defender ends the battle with at most units
+func (d Distribution) DefenderUnitsCDF(units int) float64 {
+	return d.cdf(units, func(s BattleState) int { return s.DefenderUnits })
+}
+
+// Smallest number of attacker units left such that AttackerUnitsCDF is at
+// least p, with p between 0 and 1
+func (d Distribution) AttackerUnitsPercentile(p float64) int {
+	return d.percentile(p, func(s BattleState) int { return s.AttackerUnits })
+}
+
+// Smallest number of defender units left such that DefenderUnitsCDF is at
+// least p, with p between 0 and 1
+func (d Distribution) DefenderUnitsPercentile(p float64) int {
+	return d.percentile(p, func(s BattleState) int { return s.DefenderUnits })
+}
+
+func (d Distribution) cdf(units int, side func(BattleState) int) float64 {
+	cumulative := 0.0
+	for state, prob := range d {
+		if side(state) <= units {
+			cumulative += prob
+		}
+	}
+	return cumulative
+}
+
+func (d Distribution) percentile(p float64, side func(BattleState) int) int {
+	marginal := map[int]float64{}
+	for state, prob := range d {
+		marginal[side(state)] += prob
+	}
+	units := slices.Sorted(maps.Keys(marginal))
+	cumulative := 0.0
+	for _, u := range units {
+		cumulative += marginal[u]
+		// Tolerate rounding errors when summing probabilities
+		if cumulative >= p-1e-12 {
+			return u
+		}
+	}
+	if len(units) == 0 {
+		return 0
+	}
+	return units[len(units)-1]
+}
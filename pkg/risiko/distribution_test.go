@@ -0,0 +1,43 @@
+package risiko
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistributionHelpers(t *testing.T) {
+	initial := BattleState{AttackerUnits: 5, DefenderUnits: 4}
+	dist := Distribution{
+		{AttackerUnits: 1, DefenderUnits: 2}: 0.25,
+		{AttackerUnits: 1, DefenderUnits: 4}: 0.25,
+		{AttackerUnits: 3, DefenderUnits: 0}: 0.25,
+		{AttackerUnits: 5, DefenderUnits: 0}: 0.25,
+	}
+	testCases := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{name: "win probability", got: dist.WinProbability(), want: 0.5},
+		{name: "expected attackers left", got: dist.ExpectedAttackerUnitsLeft(), want: 2.5},
+		{name: "attackers left on win", got: dist.AttackerUnitsLeftOnWin(), want: 4},
+		{name: "expected defenders left", got: dist.ExpectedDefenderUnitsLeft(), want: 1.5},
+		{name: "expected defender losses", got: dist.ExpectedDefenderLosses(initial), want: 2.5},
+		{name: "defenders left on loss", got: dist.DefenderUnitsLeftOnLoss(), want: 3},
+		{name: "attacker cdf below", got: dist.AttackerUnitsCDF(0), want: 0},
+		{name: "attacker cdf", got: dist.AttackerUnitsCDF(3), want: 0.75},
+		{name: "at least 5 attackers", got: 1 - dist.AttackerUnitsCDF(4), want: 0.25},
+		{name: "defender cdf", got: dist.DefenderUnitsCDF(2), want: 0.75},
+		{name: "attacker median", got: float64(dist.AttackerUnitsPercentile(0.5)), want: 1},
+		{name: "attacker 90th percentile", got: float64(dist.AttackerUnitsPercentile(0.9)), want: 5},
+		{name: "defender 75th percentile", got: float64(dist.DefenderUnitsPercentile(0.75)), want: 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if math.Abs(tc.got-tc.want) > 1e-12 {
+				t.Errorf("Expected %f but got %f", tc.want, tc.got)
+			}
+		})
+	}
+}
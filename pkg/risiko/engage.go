@@ -3,10 +3,23 @@ package risiko
 import (
 	"fmt"
 	"slices"
+	"sync"
 )
 
-const ENGAGE_RULE_MAX_UNITS = 3
-const ENGAGE_RULE_MIN_ATTACK = 2
+// Side of a battle
+type Side int
+
+const (
+	AttackerSide Side = iota
+	DefenderSide
+)
+
+func (s Side) String() string {
+	if s == AttackerSide {
+		return "attacker"
+	}
+	return "defender"
+}
 
 type EngageStrategy interface {
 	UpdateState(BattleState)
@@ -18,6 +31,7 @@ type EngageStrategy interface {
 ///////////////////////////////////////////////////////////////////////////////
 
 type maxAttackers struct {
+	rules    Rules
 	genDices DicesGenerator
 	state    BattleState
 }
@@ -27,7 +41,7 @@ func (m *maxAttackers) UpdateState(state BattleState) {
 }
 
 func (m *maxAttackers) GetDices() (Dices, error) {
-	nUnits, err := getMaxAttackers(m.state.AttackerUnits)
+	nUnits, err := getMaxAttackers(m.rules, m.state.AttackerUnits)
 	if err != nil {
 		return nil, err
 	}
@@ -38,21 +52,121 @@ func (m *maxAttackers) GetDices() (Dices, error) {
 	return dices, nil
 }
 
-func getMaxAttackers(units int) (int, error) {
-	if units < ENGAGE_RULE_MIN_ATTACK {
-		return 0, fmt.Errorf("cannot attack with 1 unit")
-	} else if units > ENGAGE_RULE_MAX_UNITS {
-		return ENGAGE_RULE_MAX_UNITS, nil
+func getMaxAttackers(rules Rules, units int) (int, error) {
+	if units < rules.MinAttackUnits {
+		return 0, fmt.Errorf("%w: cannot attack with %d units", ErrNotEnoughAttackers, units)
+	} else if units-rules.UnitsStayBehind > rules.MaxAttackDices {
+		return rules.MaxAttackDices, nil
 	} else {
-		return units - 1, nil
+		return units - rules.UnitsStayBehind, nil
 	}
 }
 
+///////////////////////////////////////////////////////////////////////////////
+// Retreat attackers -> Attack with maximum units until the battle turns bad
+///////////////////////////////////////////////////////////////////////////////
+
+// Thresholds below which the attacker retreats. Zero values are ignored.
+type RetreatThresholds struct {
+	// Units the attacker wants to keep
+	MinUnits int
+	// Attacker units over defender units
+	MinRatio float64
+	// Probability of winning from the current state, estimated exactly for an
+	// attacker that never retreats
+	MinWinProbability float64
+}
+
+// Solvers are shared by all the battles of the strategy. A solver isn't safe
+// for concurrent use, so battles running at the same time take different ones
+// from the pool of their rules and share the odds already solved.
+type retreatSolvers struct {
+	mu    sync.Mutex
+	pools map[Rules]*sync.Pool
+	// Win probability of each retreatOdds
+	odds sync.Map
+}
+
+type retreatOdds struct {
+	rules Rules
+	state BattleState
+}
+
+func (r *retreatSolvers) winProbability(rules Rules, state BattleState) (float64, error) {
+	key := retreatOdds{rules: rules, state: state}
+	if p, ok := r.odds.Load(key); ok {
+		return p.(float64), nil
+	}
+	pool, err := r.pool(rules)
+	if err != nil {
+		return 0, err
+	}
+	solver := pool.Get().(*Solver)
+	p := solver.solve(state).WinProbability()
+	pool.Put(solver)
+	r.odds.Store(key, p)
+	return p, nil
+}
+
+// Pool of solvers of the rules, only locking to find or add it
+func (r *retreatSolvers) pool(rules Rules) (*sync.Pool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if pool, ok := r.pools[rules]; ok {
+		return pool, nil
+	}
+	if err := rules.Validate(); err != nil {
+		return nil, err
+	}
+	pool := &sync.Pool{New: func() any {
+		// The rules are already valid
+		solver, _ := NewSolver(rules)
+		return solver
+	}}
+	r.pools[rules] = pool
+	return pool, nil
+}
+
+type retreatAttackers struct {
+	maxAttackers
+	thresholds RetreatThresholds
+	solvers    *retreatSolvers
+}
+
+func (r *retreatAttackers) GetDices() (Dices, error) {
+	retreat, err := r.shouldRetreat()
+	if err != nil {
+		return nil, err
+	} else if retreat {
+		return nil, ErrRetreat
+	}
+	return r.maxAttackers.GetDices()
+}
+
+func (r *retreatAttackers) shouldRetreat() (bool, error) {
+	state := r.state
+	if state.AttackerUnits < r.thresholds.MinUnits {
+		return true, nil
+	}
+	if r.thresholds.MinRatio > 0 && float64(state.AttackerUnits) < r.thresholds.MinRatio*float64(state.DefenderUnits) {
+		return true, nil
+	}
+	if r.thresholds.MinWinProbability > 0 {
+		p, err := r.solvers.winProbability(r.rules, state)
+		if err != nil {
+			return false, err
+		}
+		return p < r.thresholds.MinWinProbability, nil
+	}
+	return false, nil
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // Max defenders -> Always defend with maximum units
 ///////////////////////////////////////////////////////////////////////////////
 
 type maxDefenders struct {
+	rules    Rules
 	genDices DicesGenerator
 	state    BattleState
 }
@@ -62,7 +176,7 @@ func (m *maxDefenders) UpdateState(state BattleState) {
 }
 
 func (m *maxDefenders) GetDices() (Dices, error) {
-	nUnits, err := getMaxDefenders(m.state.DefenderUnits)
+	nUnits, err := getMaxDefenders(m.rules, m.state.DefenderUnits)
 	if err != nil {
 		return nil, err
 	}
@@ -73,48 +187,102 @@ func (m *maxDefenders) GetDices() (Dices, error) {
 	return dices, nil
 }
 
-func getMaxDefenders(availableDefenders int) (int, error) {
+func getMaxDefenders(rules Rules, availableDefenders int) (int, error) {
 	if availableDefenders <= 0 {
-		return 0, fmt.Errorf("cannot defend with 0 units")
-	} else if availableDefenders >= ENGAGE_RULE_MAX_UNITS {
-		return ENGAGE_RULE_MAX_UNITS, nil
+		return 0, fmt.Errorf("%w: cannot defend with %d units", ErrNoDefenders, availableDefenders)
+	} else if availableDefenders >= rules.MaxDefenceDices {
+		return rules.MaxDefenceDices, nil
 	} else {
 		return availableDefenders, nil
 	}
 }
 
 ///////////////////////////////////////////////////////////////////////////////
-// Engage function
+// Policy dices -> Throw as many dices as a policy decides for the battle state
+///////////////////////////////////////////////////////////////////////////////
+
+// Chooses how many dices a side throws given the battle state and the most
+// dices it's allowed to throw
+type DicesPolicy = func(state BattleState, maxDices int) int
+
+// Throws the most dices allowed, the policy of the max strategies
+func MaxDicesPolicy(state BattleState, maxDices int) int {
+	return maxDices
+}
+
+// Never throws more than n dices
+func NewCappedDicesPolicy(n int) DicesPolicy {
+	return func(state BattleState, maxDices int) int {
+		return min(n, maxDices)
+	}
+}
+
+type policyDices struct {
+	rules    Rules
+	side     Side
+	policy   DicesPolicy
+	genDices DicesGenerator
+	state    BattleState
+}
+
+func (p *policyDices) UpdateState(state BattleState) {
+	p.state = state
+}
+
+func (p *policyDices) GetDices() (Dices, error) {
+	maxDices, err := getMaxDices(p.rules, p.side, p.state)
+	if err != nil {
+		return nil, err
+	}
+	return p.genDices(p.policy(p.state, maxDices))
+}
+
+// Most dices the side can throw in the given state
+func getMaxDices(rules Rules, side Side, state BattleState) (int, error) {
+	if side == AttackerSide {
+		return getMaxAttackers(rules, state.AttackerUnits)
+	}
+	return getMaxDefenders(rules, state.DefenderUnits)
+}
 
-// Rolls the dices used by the attacker and the defender and compares results to
-// establish units lost per side. Returns attacker loss followed by defender
-// loss.
-func engage(attacker Dices, defender Dices) (int, int) {
-	// Roll
-	attackerThrows := attacker.Roll()
-	defenderThrows := defender.Roll()
+///////////////////////////////////////////////////////////////////////////////
+// Compare throws
 
+// Compares attacker and defender throws highest against highest, resolving
+// ties as the rules say. Returns attacker loss followed by defender loss.
+func compareThrows(rules Rules, attackerThrows []int, defenderThrows []int) (int, int) {
 	// Prepare throws for comparison
 	slices.Sort(attackerThrows)
 	slices.Sort(defenderThrows)
 	slices.Reverse(attackerThrows)
 	slices.Reverse(defenderThrows)
-	nCompare := defender.Count()
-	if attacker.Count() < defender.Count() {
-		nCompare = attacker.Count()
-	}
+	nCompare := min(len(attackerThrows), len(defenderThrows))
 
 	// Compare
 	attackerLoss := 0
 	defenderLoss := 0
 	for i := range nCompare {
-		attDice := attackerThrows[i]
-		defDice := defenderThrows[i]
-		if attDice > defDice {
-			defenderLoss += 1
-		} else {
-			attackerLoss += 1
-		}
+		attLoss, defLoss := compareDices(rules, attackerThrows[i], defenderThrows[i])
+		attackerLoss += attLoss
+		defenderLoss += defLoss
 	}
 	return attackerLoss, defenderLoss
 }
+
+// Compares a single attacker dice against a defender dice. Returns attacker
+// loss followed by defender loss.
+func compareDices(rules Rules, attDice int, defDice int) (int, int) {
+	if attDice > defDice {
+		return 0, 1
+	} else if attDice < defDice {
+		return 1, 0
+	}
+	switch rules.Ties {
+	case AttackerWinsTies:
+		return 0, 1
+	case BothLoseOnTies:
+		return 1, 1
+	default:
+		return 1, 0
+	}
+}
@@ -1,7 +1,11 @@
 package risiko
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"slices"
+	"sync"
 	"testing"
 )
 
@@ -22,7 +26,7 @@ func (l *loadedDices) Roll() []int {
 }
 
 func TestMaxAttackersStrategy(t *testing.T) {
-	strategy := &maxAttackers{genDices: FairDicesGen}
+	strategy := &maxAttackers{rules: RisiKoRules, genDices: FairDicesGen}
 	testCases := []struct {
 		state     BattleState
 		wantDices int
@@ -68,7 +72,7 @@ func TestMaxAttackersStrategy(t *testing.T) {
 }
 
 func TestMaxDefendersStrategy(t *testing.T) {
-	strategy := &maxDefenders{genDices: FairDicesGen}
+	strategy := &maxDefenders{rules: RisiKoRules, genDices: FairDicesGen}
 	testCases := []struct {
 		state     BattleState
 		wantDices int
@@ -119,42 +123,42 @@ func TestMaxDefendersStrategy(t *testing.T) {
 	}
 }
 
-func TestEngage(t *testing.T) {
+func TestCompareThrows(t *testing.T) {
 	testCases := []struct {
 		name      string
-		attackers Dices
-		defenders Dices
+		attackers []int
+		defenders []int
 		want      []int
 	}{
 		{
 			name:      "same throws",
-			attackers: getLoadedDices([]int{6, 6, 6}),
-			defenders: getLoadedDices([]int{6, 6, 6}),
+			attackers: []int{6, 6, 6},
+			defenders: []int{6, 6, 6},
 			want:      []int{3, 0},
 		},
 		{
 			name:      "same throws different dices",
-			attackers: getLoadedDices([]int{6, 6, 6}),
-			defenders: getLoadedDices([]int{6}),
+			attackers: []int{6, 6, 6},
+			defenders: []int{6},
 			want:      []int{1, 0},
 		},
 		{
 			name:      "6 4 1 vs 5 5 1",
-			attackers: getLoadedDices([]int{6, 4, 1}),
-			defenders: getLoadedDices([]int{5, 5, 1}),
+			attackers: []int{6, 4, 1},
+			defenders: []int{5, 5, 1},
 			want:      []int{2, 1},
 		},
 		{
 			name:      "4",
-			attackers: getLoadedDices([]int{4}),
-			defenders: getLoadedDices([]int{4}),
+			attackers: []int{4},
+			defenders: []int{4},
 			want:      []int{1, 0},
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			attackerLoss, defenderLoss := engage(tc.attackers, tc.defenders)
+			attackerLoss, defenderLoss := compareThrows(RisiKoRules, tc.attackers, tc.defenders)
 			if tc.want[0] != attackerLoss {
 				t.Errorf("Expected attacker loss %d but got %d", attackerLoss, tc.want[0])
 			}
@@ -164,3 +168,157 @@ func TestEngage(t *testing.T) {
 		})
 	}
 }
+
+func TestCompareThrowsRules(t *testing.T) {
+	attackers := []int{6, 4, 2}
+	defenders := []int{6, 3, 2}
+	testCases := []struct {
+		name  string
+		rules Rules
+		want  []int
+	}{
+		{name: "defender wins ties", rules: Rules{Ties: DefenderWinsTies}, want: []int{2, 1}},
+		{name: "attacker wins ties", rules: Rules{Ties: AttackerWinsTies}, want: []int{0, 3}},
+		{name: "both lose on ties", rules: Rules{Ties: BothLoseOnTies}, want: []int{2, 3}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			attackerLoss, defenderLoss := compareThrows(tc.rules, slices.Clone(attackers), slices.Clone(defenders))
+			if tc.want[0] != attackerLoss {
+				t.Errorf("Expected attacker loss %d but got %d", tc.want[0], attackerLoss)
+			}
+			if tc.want[1] != defenderLoss {
+				t.Errorf("Expected defender loss %d but got %d", tc.want[1], defenderLoss)
+			}
+		})
+	}
+}
+
+func TestGetMaxDicesRules(t *testing.T) {
+	rules := Rules{MaxAttackDices: 2, MaxDefenceDices: 2, MinAttackUnits: 1}
+	for units, want := range map[int]int{1: 1, 2: 2, 5: 2} {
+		if got, err := getMaxAttackers(rules, units); err != nil || got != want {
+			t.Errorf("Expected %d attacker dices for %d units but got %d (%v)", want, units, got, err)
+		}
+		if got, err := getMaxDefenders(rules, units); err != nil || got != want {
+			t.Errorf("Expected %d defender dices for %d units but got %d (%v)", want, units, got, err)
+		}
+	}
+	if _, err := getMaxAttackers(rules, 0); err == nil {
+		t.Errorf("Expected attacking with no units to fail")
+	}
+}
+
+func TestRetreatAttackersStrategy(t *testing.T) {
+	testCases := []struct {
+		name        string
+		thresholds  RetreatThresholds
+		state       BattleState
+		wantRetreat bool
+	}{
+		{name: "no thresholds", state: BattleState{AttackerUnits: 2, DefenderUnits: 30}},
+		{name: "enough units", thresholds: RetreatThresholds{MinUnits: 5}, state: BattleState{AttackerUnits: 5, DefenderUnits: 3}},
+		{name: "too few units", thresholds: RetreatThresholds{MinUnits: 5}, state: BattleState{AttackerUnits: 4, DefenderUnits: 3}, wantRetreat: true},
+		{name: "good ratio", thresholds: RetreatThresholds{MinRatio: 1.5}, state: BattleState{AttackerUnits: 6, DefenderUnits: 4}},
+		{name: "bad ratio", thresholds: RetreatThresholds{MinRatio: 1.5}, state: BattleState{AttackerUnits: 5, DefenderUnits: 4}, wantRetreat: true},
+		{name: "likely win", thresholds: RetreatThresholds{MinWinProbability: 0.5}, state: BattleState{AttackerUnits: 10, DefenderUnits: 2}},
+		{name: "unlikely win", thresholds: RetreatThresholds{MinWinProbability: 0.5}, state: BattleState{AttackerUnits: 2, DefenderUnits: 10}, wantRetreat: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			strategy := NewRetreatAttackersStrategy(FairDicesGen, tc.thresholds)(RisiKoRules)
+			strategy.UpdateState(tc.state)
+			dices, err := strategy.GetDices()
+			if tc.wantRetreat {
+				if !errors.Is(err, ErrRetreat) {
+					t.Errorf("Expected retreat but got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			if dices.Count() != 3 && dices.Count() != tc.state.AttackerUnits-1 {
+				t.Errorf("Expected maximum dices but got %d", dices.Count())
+			}
+		})
+	}
+}
+
+func TestRetreatSolversConcurrent(t *testing.T) {
+	solver, err := NewSolver(RisiKoRules)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	want := map[BattleState]float64{}
+	for a := 2; a <= 20; a++ {
+		for d := 1; d <= 10; d++ {
+			state := BattleState{AttackerUnits: a, DefenderUnits: d}
+			dist, _ := solver.Solve(state)
+			want[state] = dist.WinProbability()
+		}
+	}
+
+	solvers := &retreatSolvers{pools: map[Rules]*sync.Pool{}}
+	var wg sync.WaitGroup
+	for worker := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for a := 2; a <= 20; a++ {
+				state := BattleState{AttackerUnits: a, DefenderUnits: 1 + (a+worker)%10}
+				got, err := solvers.winProbability(RisiKoRules, state)
+				if err != nil {
+					t.Errorf("Unexpected error %v", err)
+					return
+				}
+				// Solvers may sum the final states in a different order
+				if math.Abs(got-want[state]) > 1e-12 {
+					t.Errorf("Expected win probability %f at %v but got %f", want[state], state, got)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if _, err := solvers.winProbability(Rules{}, BattleState{AttackerUnits: 3, DefenderUnits: 1}); !errors.Is(err, ErrInvalidRules) {
+		t.Errorf("Expected invalid rules but got %v", err)
+	}
+}
+
+func TestPolicyStrategies(t *testing.T) {
+	// Defends with 2 dices when the attacker throws less than 3
+	defenderPolicy := func(state BattleState, maxDices int) int {
+		if state.AttackerUnits <= 3 {
+			return min(2, maxDices)
+		}
+		return maxDices
+	}
+	testCases := []struct {
+		name      string
+		strategy  BattleStrategy
+		state     BattleState
+		wantDices int
+	}{
+		{name: "max attackers", strategy: NewPolicyAttackersStrategy(FairDicesGen, MaxDicesPolicy), state: BattleState{AttackerUnits: 10, DefenderUnits: 1}, wantDices: 3},
+		{name: "capped attackers", strategy: NewPolicyAttackersStrategy(FairDicesGen, NewCappedDicesPolicy(1)), state: BattleState{AttackerUnits: 10, DefenderUnits: 1}, wantDices: 1},
+		{name: "capped above max", strategy: NewPolicyAttackersStrategy(FairDicesGen, NewCappedDicesPolicy(5)), state: BattleState{AttackerUnits: 3, DefenderUnits: 1}, wantDices: 2},
+		{name: "defender conserving", strategy: NewPolicyDefendersStrategy(FairDicesGen, defenderPolicy), state: BattleState{AttackerUnits: 3, DefenderUnits: 5}, wantDices: 2},
+		{name: "defender at max", strategy: NewPolicyDefendersStrategy(FairDicesGen, defenderPolicy), state: BattleState{AttackerUnits: 4, DefenderUnits: 5}, wantDices: 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			strategy := tc.strategy(RisiKoRules)
+			strategy.UpdateState(tc.state)
+			dices, err := strategy.GetDices()
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			if dices.Count() != tc.wantDices {
+				t.Errorf("Expected %d dices but got %d", tc.wantDices, dices.Count())
+			}
+		})
+	}
+}
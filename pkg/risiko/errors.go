@@ -0,0 +1,64 @@
+package risiko
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// The attacker doesn't have the units the rules require to attack
+	ErrNotEnoughAttackers = errors.New("not enough attackers")
+	// The defender has no units left to defend with
+	ErrNoDefenders = errors.New("no defenders")
+	// Dices were requested or thrown in a number the rules don't allow
+	ErrInvalidDiceCount = errors.New("invalid dice count")
+	// Dice without faces or with weights that aren't probabilities
+	ErrInvalidDice = errors.New("invalid dice")
+	// Dice values that cannot come out of a roll
+	ErrInvalidRoll = errors.New("invalid roll")
+	// Rules a battle cannot be played with
+	ErrInvalidRules = errors.New("invalid rules")
+	// Simulation option, policy objective or confidence level out of range
+	ErrInvalidOption = errors.New("invalid option")
+	// Battle state with negative units, or outside of what was solved
+	ErrInvalidState = errors.New("invalid battle state")
+	// Armies cannot be moved into the territory as requested
+	ErrInvalidConquest = errors.New("invalid conquest")
+	// A replayed battle doesn't go as its transcript says
+	ErrReplayMismatch = errors.New("replay does not match transcript")
+	// Returned by the attacker GetDices to stop the battle before it's over
+	ErrRetreat = errors.New("attacker retreats")
+)
+
+// Returned by Battle when a strategy throws a number of dices the rules don't
+// allow. It matches ErrInvalidDiceCount.
+type InvalidDiceCountError struct {
+	Side  Side
+	State BattleState
+	Count int
+	Max   int
+}
+
+func (e *InvalidDiceCountError) Error() string {
+	return fmt.Sprintf("%v: %v threw %d dices with %v, must be between 1 and %d", ErrInvalidDiceCount, e.Side, e.Count, e.State, e.Max)
+}
+
+func (e *InvalidDiceCountError) Is(target error) bool {
+	return target == ErrInvalidDiceCount
+}
+
+// Returned by Battle when a strategy fails to provide its dices. It wraps the
+// strategy error.
+type StrategyError struct {
+	Side  Side
+	State BattleState
+	Err   error
+}
+
+func (e *StrategyError) Error() string {
+	return fmt.Sprintf("%v strategy failed with %v: %v", e.Side, e.State, e.Err)
+}
+
+func (e *StrategyError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,162 @@
+package risiko
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestErrorsIs(t *testing.T) {
+	errBroken := errors.New("broken dices")
+	brokenGen := func(int) (Dices, error) { return nil, errBroken }
+
+	testCases := []struct {
+		name string
+		run  func() error
+		want error
+	}{
+		{
+			name: "negative dices",
+			run: func() error {
+				_, err := FairDicesGen(-1)
+				return err
+			},
+			want: ErrInvalidDiceCount,
+		},
+		{
+			name: "dices outside of the rules",
+			run: func() error {
+				_, err := Battle(RisiKoRules, BattleState{AttackerUnits: 3, DefenderUnits: 3},
+					NewPolicyAttackersStrategy(FairDicesGen, func(BattleState, int) int { return 3 }),
+					NewMaxDefendersStrategy(FairDicesGen))
+				return err
+			},
+			want: ErrInvalidDiceCount,
+		},
+		{
+			name: "not enough attackers",
+			run: func() error {
+				_, err := getMaxAttackers(RisiKoRules, 1)
+				return err
+			},
+			want: ErrNotEnoughAttackers,
+		},
+		{
+			name: "no defenders",
+			run: func() error {
+				_, err := getMaxDefenders(RisiKoRules, 0)
+				return err
+			},
+			want: ErrNoDefenders,
+		},
+		{
+			name: "invalid rules",
+			run: func() error {
+				_, err := Battle(Rules{}, BattleState{AttackerUnits: 3, DefenderUnits: 3},
+					NewMaxAttackersStrategy(FairDicesGen), NewMaxDefendersStrategy(FairDicesGen))
+				return err
+			},
+			want: ErrInvalidRules,
+		},
+		{
+			name: "negative units",
+			run: func() error {
+				solver, err := NewSolver(RisiKoRules)
+				if err != nil {
+					return err
+				}
+				_, err = solver.Solve(BattleState{AttackerUnits: -1})
+				return err
+			},
+			want: ErrInvalidState,
+		},
+		{
+			name: "conquest of a defended territory",
+			run: func() error {
+				_, err := Conquer(RisiKoRules, BattleState{AttackerUnits: 3, DefenderUnits: 1}, 2, NewMinimumConquestStrategy())
+				return err
+			},
+			want: ErrInvalidConquest,
+		},
+		{
+			name: "unknown rules preset",
+			run: func() error {
+				_, err := RulesPreset("monopoly")
+				return err
+			},
+			want: ErrInvalidRules,
+		},
+		{
+			name: "unknown objective",
+			run: func() error {
+				_, err := SolveOptimalPolicy(RisiKoRules, Objective(-1), BattleState{AttackerUnits: 3, DefenderUnits: 3})
+				return err
+			},
+			want: ErrInvalidOption,
+		},
+		{
+			name: "state outside of the policy",
+			run: func() error {
+				policy, err := SolveOptimalPolicy(RisiKoRules, MaximiseWinProbability, BattleState{AttackerUnits: 3, DefenderUnits: 3})
+				if err != nil {
+					return err
+				}
+				_, err = policy.Value(BattleState{AttackerUnits: 4, DefenderUnits: 3})
+				return err
+			},
+			want: ErrInvalidState,
+		},
+		{
+			name: "no target precision",
+			run: func() error {
+				_, err := Simulate(context.Background(), 100, 3, NewMaxAttackersStrategy(FairDicesGen), NewMaxDefendersStrategy(FairDicesGen),
+					WithTargetPrecision(0, 0.95, 1000))
+				return err
+			},
+			want: ErrInvalidOption,
+		},
+		{
+			name: "impossible confidence",
+			run: func() error {
+				_, err := Simulate(context.Background(), 100, 3, NewMaxAttackersStrategy(FairDicesGen), NewMaxDefendersStrategy(FairDicesGen),
+					WithTargetPrecision(0.01, 1.5, 1000))
+				return err
+			},
+			want: ErrInvalidOption,
+		},
+		{
+			name: "broken dices",
+			run: func() error {
+				_, err := Battle(RisiKoRules, BattleState{AttackerUnits: 3, DefenderUnits: 3},
+					NewMaxAttackersStrategy(FairDicesGen), NewMaxDefendersStrategy(brokenGen))
+				return err
+			},
+			want: errBroken,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.run()
+			if !errors.Is(err, tc.want) {
+				t.Errorf("Expected %v but got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestStrategyError(t *testing.T) {
+	errBroken := errors.New("broken dices")
+	state := BattleState{AttackerUnits: 3, DefenderUnits: 3}
+	_, err := Battle(RisiKoRules, state,
+		NewMaxAttackersStrategy(func(int) (Dices, error) { return nil, errBroken }),
+		NewMaxDefendersStrategy(FairDicesGen))
+
+	var strategyErr *StrategyError
+	if !errors.As(err, &strategyErr) {
+		t.Fatalf("Expected strategy error but got %v", err)
+	}
+	if strategyErr.Side != AttackerSide || strategyErr.State != state {
+		t.Errorf("Expected the attacker to fail at %v but got %v at %v", state, strategyErr.Side, strategyErr.State)
+	}
+}
@@ -0,0 +1,157 @@
+package risiko
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+type ExactSweep = map[int]map[int]Distribution
+
+///////////////////////////////////////////////////////////////////////////////
+// Solver -> Computes exact battle outcomes as an absorbing Markov chain where
+// both sides always throw the maximum number of dices of the rules
+///////////////////////////////////////////////////////////////////////////////
+
+type engagement struct {
+	nAttackers int
+	nDefenders int
+}
+
+type engageOutcome struct {
+	attackerLoss int
+	defenderLoss int
+	probability  float64
+}
+
+// Solver caches intermediate results so it's cheap to solve many states. It
+// is not safe for concurrent use.
+type Solver struct {
+	rules    Rules
+	outcomes map[engagement][]engageOutcome
+	memo     map[BattleState]Distribution
+}
+
+func NewSolver(rules Rules) (*Solver, error) {
+	if err := rules.Validate(); err != nil {
+		return nil, err
+	}
+	return &Solver{
+		rules:    rules,
+		outcomes: map[engagement][]engageOutcome{},
+		memo:     map[BattleState]Distribution{},
+	}, nil
+}
+
+// Returns the probability of every final state of a battle starting at state
+func (s *Solver) Solve(state BattleState) (Distribution, error) {
+	if state.AttackerUnits < 0 || state.DefenderUnits < 0 {
+		return nil, fmt.Errorf("%w: cannot solve battle with negative units %v", ErrInvalidState, state)
+	}
+	return maps.Clone(s.solve(state)), nil
+}
+
+func (s *Solver) solve(state BattleState) Distribution {
+	if dist, ok := s.memo[state]; ok {
+		return dist
+	}
+	nAttackers, errAtt := getMaxAttackers(s.rules, state.AttackerUnits)
+	nDefenders, errDef := getMaxDefenders(s.rules, state.DefenderUnits)
+	if errAtt != nil || errDef != nil {
+		// Battle is over
+		dist := Distribution{state: 1}
+		s.memo[state] = dist
+		return dist
+	}
+
+	dist := Distribution{}
+	for _, outcome := range s.engageOutcomes(nAttackers, nDefenders) {
+		next := BattleState{
+			AttackerUnits: state.AttackerUnits - outcome.attackerLoss,
+			DefenderUnits: state.DefenderUnits - outcome.defenderLoss,
+		}
+		for final, prob := range s.solve(next) {
+			dist[final] += outcome.probability * prob
+		}
+	}
+	s.memo[state] = dist
+	return dist
+}
+
+// Enumerates every throw of the given dices and groups them by units lost
+func (s *Solver) engageOutcomes(nAttackers int, nDefenders int) []engageOutcome {
+	key := engagement{nAttackers: nAttackers, nDefenders: nDefenders}
+	if outcomes, ok := s.outcomes[key]; ok {
+		return outcomes
+	}
+
+	// Every throw is a number in a mixed radix with a digit per dice
+	dices := make([]*Dice, nAttackers+nDefenders)
+	nCombinations := 1
+	for i := range dices {
+		side := AttackerSide
+		if i >= nAttackers {
+			side = DefenderSide
+		}
+		dices[i] = s.rules.Dice(side)
+		nCombinations *= dices[i].Faces()
+	}
+	probabilities := map[[2]int]float64{}
+	throws := make([]int, len(dices))
+	for i := range nCombinations {
+		c := i
+		probability := 1.0
+		for j, dice := range dices {
+			throws[j] = c%dice.Faces() + 1
+			c /= dice.Faces()
+			probability *= dice.Probability(throws[j])
+		}
+		if probability == 0 {
+			continue
+		}
+		attackerThrows := slices.Clone(throws[:nAttackers])
+		defenderThrows := slices.Clone(throws[nAttackers:])
+		attackerLoss, defenderLoss := compareThrows(s.rules, attackerThrows, defenderThrows)
+		probabilities[[2]int{attackerLoss, defenderLoss}] += probability
+	}
+
+	outcomes := []engageOutcome{}
+	for losses, probability := range probabilities {
+		outcomes = append(outcomes, engageOutcome{
+			attackerLoss: losses[0],
+			defenderLoss: losses[1],
+			probability:  probability,
+		})
+	}
+	slices.SortFunc(outcomes, func(a, b engageOutcome) int {
+		return cmp.Or(cmp.Compare(a.attackerLoss, b.attackerLoss), cmp.Compare(a.defenderLoss, b.defenderLoss))
+	})
+	s.outcomes[key] = outcomes
+	return outcomes
+}
+
+// Exact equivalent of Simulate
+func SolveSweep(rules Rules, nUnitsSweep int) (ExactSweep, error) {
+	return SolveMatchups(rules, SquareMatchups(rules, nUnitsSweep))
+}
+
+// Exact equivalent of Simulate WithMatchups
+func SolveMatchups(rules Rules, matchups Matchups) (ExactSweep, error) {
+	solver, err := NewSolver(rules)
+	if err != nil {
+		return nil, err
+	}
+	sweep := ExactSweep{}
+	for _, state := range matchups {
+		dist, err := solver.Solve(state)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := sweep[state.AttackerUnits]; !ok {
+			sweep[state.AttackerUnits] = map[int]Distribution{}
+		}
+		sweep[state.AttackerUnits][state.DefenderUnits] = dist
+	}
+	return sweep, nil
+}
@@ -0,0 +1,191 @@
+package risiko
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestSolve(t *testing.T) {
+	testCases := []struct {
+		name  string
+		state BattleState
+		want  Distribution
+	}{
+		{
+			name:  "battle over",
+			state: BattleState{AttackerUnits: 1, DefenderUnits: 4},
+			want:  Distribution{{AttackerUnits: 1, DefenderUnits: 4}: 1},
+		},
+		{
+			name:  "one dice each",
+			state: BattleState{AttackerUnits: 2, DefenderUnits: 1},
+			want: Distribution{
+				{AttackerUnits: 2, DefenderUnits: 0}: 15.0 / 36,
+				{AttackerUnits: 1, DefenderUnits: 1}: 21.0 / 36,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			solver, err := NewSolver(RisiKoRules)
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			got, err := solver.Solve(tc.state)
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			if len(got) != len(tc.want) {
+				t.Errorf("Expected %d final states but got %d", len(tc.want), len(got))
+			}
+			for state, p := range tc.want {
+				if math.Abs(got[state]-p) > 1e-12 {
+					t.Errorf("Expected probability %f for %v but got %f", p, state, got[state])
+				}
+			}
+		})
+	}
+
+	solver, err := NewSolver(RisiKoRules)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if _, err := solver.Solve(BattleState{AttackerUnits: -1}); err == nil {
+		t.Errorf("Expected negative units to fail")
+	}
+}
+
+func TestSolveSweep(t *testing.T) {
+	nUnitsSweep := 10
+	sweep, err := SolveSweep(RisiKoRules, nUnitsSweep)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	for a := RisiKoRules.MinAttackUnits; a <= nUnitsSweep; a++ {
+		for d := 1; d <= nUnitsSweep; d++ {
+			t.Run(fmt.Sprintf("a%d d%d", a, d), func(t *testing.T) {
+				total := 0.0
+				for state, p := range sweep[a][d] {
+					if state.AttackerUnits < 1 || state.AttackerUnits > a || state.DefenderUnits > d {
+						t.Errorf("Unexpected final state %v", state)
+					}
+					total += p
+				}
+				if math.Abs(total-1) > 1e-9 {
+					t.Errorf("Expected probabilities to sum to 1 but got %f", total)
+				}
+			})
+		}
+	}
+}
+
+func TestSolveMatchesBattle(t *testing.T) {
+	nBattles := 20000
+	state := BattleState{AttackerUnits: 6, DefenderUnits: 4}
+	solver, err := NewSolver(RisiKoRules)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	dist, err := solver.Solve(state)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	nWon := 0
+	for range nBattles {
+		got, err := Battle(RisiKoRules, state, NewMaxAttackersStrategy(FairDicesGen), NewMaxDefendersStrategy(FairDicesGen))
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		if got.AttackerWon() {
+			nWon++
+		}
+	}
+	simulated := float64(nWon) / float64(nBattles)
+	if math.Abs(simulated-dist.WinProbability()) > 0.02 {
+		t.Errorf("Expected simulated win rate %f to be close to %f", simulated, dist.WinProbability())
+	}
+}
+
+func TestSolveCustomDice(t *testing.T) {
+	state := BattleState{AttackerUnits: 8, DefenderUnits: 6}
+	solve := func(t *testing.T, rules Rules) Distribution {
+		solver, err := NewSolver(rules)
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		dist, err := solver.Solve(state)
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		return dist
+	}
+	fair := solve(t, RisiKoRules)
+
+	t.Run("explicit six sided dices", func(t *testing.T) {
+		rules := RisiKoRules
+		rules.AttackDice = mustNewFairDice(6)
+		rules.DefenceDice = mustNewFairDice(6)
+		if got := solve(t, rules).WinProbability(); math.Abs(got-fair.WinProbability()) > 1e-12 {
+			t.Errorf("Expected win probability %f but got %f", fair.WinProbability(), got)
+		}
+	})
+
+	t.Run("eight sided attacker", func(t *testing.T) {
+		rules := RisiKoRules
+		rules.AttackDice = mustNewFairDice(8)
+		if got := solve(t, rules).WinProbability(); got <= fair.WinProbability() {
+			t.Errorf("Expected bigger dices to win more than %f but got %f", fair.WinProbability(), got)
+		}
+	})
+
+	t.Run("attacker never rolls six", func(t *testing.T) {
+		rules := RisiKoRules
+		dice, err := NewDice(1, 1, 1, 1, 1, 0)
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		rules.AttackDice = dice
+		if got := solve(t, rules).WinProbability(); got >= fair.WinProbability() {
+			t.Errorf("Expected loaded dices to win less than %f but got %f", fair.WinProbability(), got)
+		}
+	})
+}
+
+func TestSolveCustomDiceMatchesSimulate(t *testing.T) {
+	nUnitsSweep := 5
+	rules := RiskRules
+	var err error
+	if rules.AttackDice, err = NewDice(1, 1, 1, 1, 2, 2); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if rules.DefenceDice, err = NewFairDice(8); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	exact, err := SolveSweep(rules, nUnitsSweep)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	seeded, err := SimulateSeeded(context.Background(), 1, 5000, nUnitsSweep, NewMaxAttackersStrategy, NewMaxDefendersStrategy, WithRules(rules))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	// Generators built without a dice throw the one of the rules
+	global, err := Simulate(context.Background(), 5000, nUnitsSweep, NewMaxAttackersStrategy(FairDicesGen), NewMaxDefendersStrategy(FairDicesGen), WithRules(rules))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	for name, simulated := range map[string]SimulationSweep{"seeded": seeded, "global": global} {
+		for a := rules.MinAttackUnits; a <= nUnitsSweep; a++ {
+			for d := 1; d <= nUnitsSweep; d++ {
+				want := exact[a][d].WinProbability()
+				if got := simulated[a][d].WinProbability(); math.Abs(got-want) > 0.03 {
+					t.Errorf("Expected %s simulated win rate %f to be close to %f with %d attackers and %d defenders", name, got, want, a, d)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package risiko
+
+import (
+	"fmt"
+	"slices"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// Matchups -> Battles of a sweep, either a grid of attacker and defender
+// units or any list of them
+///////////////////////////////////////////////////////////////////////////////
+
+// Units from From to To included, every Step units
+type UnitsRange struct {
+	From int
+	To   int
+	Step int
+}
+
+func (r UnitsRange) Validate() error {
+	if r.From < 0 || r.To < r.From || r.Step < 1 {
+		return fmt.Errorf("%w: units range %d to %d every %d", ErrInvalidState, r.From, r.To, r.Step)
+	}
+	return nil
+}
+
+func (r UnitsRange) Units() []int {
+	units := []int{}
+	for n := r.From; n <= r.To; n += max(1, r.Step) {
+		units = append(units, n)
+	}
+	return units
+}
+
+// Initial states of the battles of a sweep
+type Matchups []BattleState
+
+// Every battle with up to nUnits units per side where the attacker can attack,
+// the sweep of Simulate and SolveSweep
+func SquareMatchups(rules Rules, nUnits int) Matchups {
+	matchups := Matchups{}
+	for nDefenders := 1; nDefenders <= nUnits; nDefenders++ {
+		for nAttackers := rules.MinAttackUnits; nAttackers <= nUnits; nAttackers++ {
+			matchups = append(matchups, BattleState{AttackerUnits: nAttackers, DefenderUnits: nDefenders})
+		}
+	}
+	return matchups
+}
+
+// Every battle between the units of attackers and the units of defenders
+func GridMatchups(attackers UnitsRange, defenders UnitsRange) (Matchups, error) {
+	if err := attackers.Validate(); err != nil {
+		return nil, err
+	}
+	if err := defenders.Validate(); err != nil {
+		return nil, err
+	}
+	matchups := Matchups{}
+	for _, nDefenders := range defenders.Units() {
+		for _, nAttackers := range attackers.Units() {
+			matchups = append(matchups, BattleState{AttackerUnits: nAttackers, DefenderUnits: nDefenders})
+		}
+	}
+	return matchups, nil
+}
+
+func (m Matchups) Validate() error {
+	for _, state := range m {
+		if state.AttackerUnits < 0 || state.DefenderUnits < 0 {
+			return fmt.Errorf("%w: matchup with negative units %v", ErrInvalidState, state)
+		}
+	}
+	return nil
+}
+
+// Attacker units of the matchups in ascending order, without repetitions
+func (m Matchups) Attackers() []int {
+	return m.sortedUnits(func(s BattleState) int { return s.AttackerUnits })
+}
+
+// Defender units of the matchups in ascending order, without repetitions
+func (m Matchups) Defenders() []int {
+	return m.sortedUnits(func(s BattleState) int { return s.DefenderUnits })
+}
+
+func (m Matchups) sortedUnits(side func(BattleState) int) []int {
+	units := []int{}
+	for _, state := range m {
+		units = append(units, side(state))
+	}
+	slices.Sort(units)
+	return slices.Compact(units)
+}
+
+// Matchups in the same order without repetitions
+func (m Matchups) unique() Matchups {
+	seen := map[BattleState]bool{}
+	unique := Matchups{}
+	for _, state := range m {
+		if !seen[state] {
+			seen[state] = true
+			unique = append(unique, state)
+		}
+	}
+	return unique
+}
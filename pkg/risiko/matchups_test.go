@@ -0,0 +1,48 @@
+package risiko
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestGridMatchups(t *testing.T) {
+	matchups, err := GridMatchups(UnitsRange{From: 10, To: 22, Step: 5}, UnitsRange{From: 1, To: 2, Step: 1})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	want := Matchups{
+		{AttackerUnits: 10, DefenderUnits: 1},
+		{AttackerUnits: 15, DefenderUnits: 1},
+		{AttackerUnits: 20, DefenderUnits: 1},
+		{AttackerUnits: 10, DefenderUnits: 2},
+		{AttackerUnits: 15, DefenderUnits: 2},
+		{AttackerUnits: 20, DefenderUnits: 2},
+	}
+	if !slices.Equal(matchups, want) {
+		t.Errorf("Expected %v but got %v", want, matchups)
+	}
+	if got := matchups.Attackers(); !slices.Equal(got, []int{10, 15, 20}) {
+		t.Errorf("Expected attackers [10 15 20] but got %v", got)
+	}
+	if got := matchups.Defenders(); !slices.Equal(got, []int{1, 2}) {
+		t.Errorf("Expected defenders [1 2] but got %v", got)
+	}
+
+	invalid := []UnitsRange{{From: -1, To: 3, Step: 1}, {From: 5, To: 3, Step: 1}, {From: 1, To: 3}}
+	for _, r := range invalid {
+		if _, err := GridMatchups(r, UnitsRange{From: 1, To: 1, Step: 1}); !errors.Is(err, ErrInvalidState) {
+			t.Errorf("Expected range %+v to be invalid but got %v", r, err)
+		}
+	}
+}
+
+func TestSquareMatchups(t *testing.T) {
+	matchups := SquareMatchups(RisiKoRules, 3)
+	if len(matchups) != 6 {
+		t.Errorf("Expected 6 matchups but got %d", len(matchups))
+	}
+	if got := matchups.Attackers(); !slices.Equal(got, []int{2, 3}) {
+		t.Errorf("Expected attackers [2 3] but got %v", got)
+	}
+}
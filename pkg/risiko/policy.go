@@ -0,0 +1,214 @@
+package risiko
+
+import (
+	"fmt"
+)
+
+// What the attacker tries to maximise and the defender to minimise
+type Objective int
+
+const (
+	MaximiseWinProbability Objective = iota
+	MaximiseExpectedUnitsLeft
+)
+
+func (o Objective) String() string {
+	switch o {
+	case MaximiseWinProbability:
+		return "win probability"
+	case MaximiseExpectedUnitsLeft:
+		return "expected units left"
+	default:
+		return fmt.Sprintf("Objective(%d)", int(o))
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// Optimal policy -> Dices thrown by each side when both play perfectly, solved
+// by dynamic programming from the end of the battle backwards
+///////////////////////////////////////////////////////////////////////////////
+
+// Dices of each side for every state up to a maximum. The attacker chooses
+// its dices first and the defender answers knowing them. It is read only once
+// solved, so it's safe for concurrent use.
+type OptimalPolicy struct {
+	rules     Rules
+	objective Objective
+	maxState  BattleState
+	// Indexed by attacker units then defender units
+	value         [][]float64
+	attackerDices [][]int
+	// Indexed by attacker units, defender units then attacker dices
+	defenderDices [][][]int
+}
+
+// Solves the policy for every state with up to maxState attacker and defender
+// units
+func SolveOptimalPolicy(rules Rules, objective Objective, maxState BattleState) (*OptimalPolicy, error) {
+	if objective != MaximiseWinProbability && objective != MaximiseExpectedUnitsLeft {
+		return nil, fmt.Errorf("%w: unknown objective %v", ErrInvalidOption, objective)
+	}
+	if maxState.AttackerUnits < 0 || maxState.DefenderUnits < 0 {
+		return nil, fmt.Errorf("%w: cannot solve policy with negative units %v", ErrInvalidState, maxState)
+	}
+	solver, err := NewSolver(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &OptimalPolicy{
+		rules:         rules,
+		objective:     objective,
+		maxState:      maxState,
+		value:         make([][]float64, maxState.AttackerUnits+1),
+		attackerDices: make([][]int, maxState.AttackerUnits+1),
+		defenderDices: make([][][]int, maxState.AttackerUnits+1),
+	}
+	// Engagements only take units away, so the states they lead to are
+	// always solved before the states they come from
+	for a := 0; a <= maxState.AttackerUnits; a++ {
+		p.value[a] = make([]float64, maxState.DefenderUnits+1)
+		p.attackerDices[a] = make([]int, maxState.DefenderUnits+1)
+		p.defenderDices[a] = make([][]int, maxState.DefenderUnits+1)
+		for d := 0; d <= maxState.DefenderUnits; d++ {
+			p.solveState(solver, BattleState{AttackerUnits: a, DefenderUnits: d})
+		}
+	}
+	return p, nil
+}
+
+func (p *OptimalPolicy) solveState(solver *Solver, state BattleState) {
+	a, d := state.AttackerUnits, state.DefenderUnits
+	maxAttackers, errAtt := getMaxAttackers(p.rules, a)
+	maxDefenders, errDef := getMaxDefenders(p.rules, d)
+	if errAtt != nil || errDef != nil {
+		// Battle is over
+		p.value[a][d] = p.terminalValue(state)
+		return
+	}
+
+	const epsilon = 1e-12
+	p.defenderDices[a][d] = make([]int, maxAttackers+1)
+	bestValue := -1.0
+	// Ties go to more dices, so max dices are only left when it's strictly
+	// better to do so
+	for nAttackers := maxAttackers; nAttackers >= 1; nAttackers-- {
+		worstValue := 0.0
+		worstDefenders := 0
+		for nDefenders := maxDefenders; nDefenders >= 1; nDefenders-- {
+			v := 0.0
+			for _, outcome := range solver.engageOutcomes(nAttackers, nDefenders) {
+				v += outcome.probability * p.value[a-outcome.attackerLoss][d-outcome.defenderLoss]
+			}
+			if worstDefenders == 0 || v < worstValue-epsilon {
+				worstValue = v
+				worstDefenders = nDefenders
+			}
+		}
+		p.defenderDices[a][d][nAttackers] = worstDefenders
+		if worstValue > bestValue+epsilon {
+			bestValue = worstValue
+			p.attackerDices[a][d] = nAttackers
+		}
+	}
+	p.value[a][d] = bestValue
+}
+
+func (p *OptimalPolicy) terminalValue(state BattleState) float64 {
+	if p.objective == MaximiseExpectedUnitsLeft {
+		return float64(state.AttackerUnits)
+	}
+	if state.AttackerWon() {
+		return 1
+	}
+	return 0
+}
+
+func (p *OptimalPolicy) contains(state BattleState) bool {
+	return state.AttackerUnits >= 0 && state.AttackerUnits <= p.maxState.AttackerUnits &&
+		state.DefenderUnits >= 0 && state.DefenderUnits <= p.maxState.DefenderUnits
+}
+
+// Win probability or expected units left when both sides play the policy
+func (p *OptimalPolicy) Value(state BattleState) (float64, error) {
+	if !p.contains(state) {
+		return 0, fmt.Errorf("%w: state %v is outside of the solved policy up to %v", ErrInvalidState, state, p.maxState)
+	}
+	return p.value[state.AttackerUnits][state.DefenderUnits], nil
+}
+
+// Dices the attacker throws in state, 0 when the battle is over
+func (p *OptimalPolicy) AttackerDices(state BattleState) (int, error) {
+	if !p.contains(state) {
+		return 0, fmt.Errorf("%w: state %v is outside of the solved policy up to %v", ErrInvalidState, state, p.maxState)
+	}
+	return p.attackerDices[state.AttackerUnits][state.DefenderUnits], nil
+}
+
+// Dices the defender throws in state against the given attacker dices, 0 when
+// the battle is over
+func (p *OptimalPolicy) DefenderDices(state BattleState, attackerDices int) (int, error) {
+	if !p.contains(state) {
+		return 0, fmt.Errorf("%w: state %v is outside of the solved policy up to %v", ErrInvalidState, state, p.maxState)
+	}
+	answers := p.defenderDices[state.AttackerUnits][state.DefenderUnits]
+	if attackerDices < 1 || attackerDices >= len(answers) {
+		return 0, nil
+	}
+	return answers[attackerDices], nil
+}
+
+// States where the side doesn't throw the most dices it's allowed to, with
+// the defender answering the attacker policy
+func (p *OptimalPolicy) StatesBelowMaxDices(side Side) []BattleState {
+	states := []BattleState{}
+	for a := 0; a <= p.maxState.AttackerUnits; a++ {
+		for d := 0; d <= p.maxState.DefenderUnits; d++ {
+			state := BattleState{AttackerUnits: a, DefenderUnits: d}
+			nDices := p.onPathDices(side, state)
+			if nDices == 0 {
+				continue
+			}
+			maxDices, _ := getMaxDices(p.rules, side, state)
+			if nDices < maxDices {
+				states = append(states, state)
+			}
+		}
+	}
+	return states
+}
+
+// Dices thrown by the side when the attacker follows the policy
+func (p *OptimalPolicy) onPathDices(side Side, state BattleState) int {
+	attackerDices := p.attackerDices[state.AttackerUnits][state.DefenderUnits]
+	if side == AttackerSide || attackerDices == 0 {
+		return attackerDices
+	}
+	return p.defenderDices[state.AttackerUnits][state.DefenderUnits][attackerDices]
+}
+
+// Dices policy of a side, assuming the defender's opponent follows the
+// attacker policy. Outside of the solved states it throws the most dices.
+func (p *OptimalPolicy) DicesPolicy(side Side) DicesPolicy {
+	return func(state BattleState, maxDices int) int {
+		if !p.contains(state) {
+			return maxDices
+		}
+		if nDices := p.onPathDices(side, state); nDices > 0 {
+			return nDices
+		}
+		return maxDices
+	}
+}
+
+// Attacker strategy following the policy. It must play with the rules the
+// policy was solved for.
+func (p *OptimalPolicy) AttackerStrategy(gen DicesGenerator) BattleStrategy {
+	return NewPolicyAttackersStrategy(gen, p.DicesPolicy(AttackerSide))
+}
+
+// Defender strategy answering an attacker that follows the policy. It must
+// play with the rules the policy was solved for.
+func (p *OptimalPolicy) DefenderStrategy(gen DicesGenerator) BattleStrategy {
+	return NewPolicyDefendersStrategy(gen, p.DicesPolicy(DefenderSide))
+}
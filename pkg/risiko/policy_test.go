@@ -0,0 +1,119 @@
+package risiko
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestSolveOptimalPolicy(t *testing.T) {
+	maxState := BattleState{AttackerUnits: 12, DefenderUnits: 12}
+	for _, name := range RulesPresetNames() {
+		rules, err := RulesPreset(name)
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		solver, err := NewSolver(rules)
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		for _, objective := range []Objective{MaximiseWinProbability, MaximiseExpectedUnitsLeft} {
+			t.Run(fmt.Sprintf("%s %v", name, objective), func(t *testing.T) {
+				policy, err := SolveOptimalPolicy(rules, objective, maxState)
+				if err != nil {
+					t.Fatalf("Unexpected error %v", err)
+				}
+
+				// Throwing the most dices is optimal for both sides in the
+				// built-in rules, so the policy is worth as much as the max
+				// strategies
+				if states := policy.StatesBelowMaxDices(AttackerSide); len(states) > 0 {
+					t.Errorf("Expected the attacker to always throw max dices but it doesn't in %v", states)
+				}
+				if states := policy.StatesBelowMaxDices(DefenderSide); len(states) > 0 {
+					t.Errorf("Expected the defender to always throw max dices but it doesn't in %v", states)
+				}
+				for a := 0; a <= maxState.AttackerUnits; a++ {
+					for d := 0; d <= maxState.DefenderUnits; d++ {
+						state := BattleState{AttackerUnits: a, DefenderUnits: d}
+						dist, err := solver.Solve(state)
+						if err != nil {
+							t.Fatalf("Unexpected error %v", err)
+						}
+						want := dist.WinProbability()
+						if objective == MaximiseExpectedUnitsLeft {
+							want = dist.ExpectedAttackerUnitsLeft()
+						}
+						got, err := policy.Value(state)
+						if err != nil {
+							t.Fatalf("Unexpected error %v", err)
+						}
+						if math.Abs(got-want) > 1e-9 {
+							t.Errorf("Expected value %f for %v but got %f", want, state, got)
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestOptimalPolicyDices(t *testing.T) {
+	policy, err := SolveOptimalPolicy(RisiKoRules, MaximiseWinProbability, BattleState{AttackerUnits: 5, DefenderUnits: 5})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	testCases := []struct {
+		name          string
+		state         BattleState
+		wantAttackers int
+		wantDefenders int
+	}{
+		{name: "full", state: BattleState{AttackerUnits: 5, DefenderUnits: 5}, wantAttackers: 3, wantDefenders: 3},
+		{name: "few attackers", state: BattleState{AttackerUnits: 2, DefenderUnits: 5}, wantAttackers: 1, wantDefenders: 3},
+		{name: "few defenders", state: BattleState{AttackerUnits: 5, DefenderUnits: 2}, wantAttackers: 3, wantDefenders: 2},
+		{name: "over", state: BattleState{AttackerUnits: 1, DefenderUnits: 2}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			nAttackers, err := policy.AttackerDices(tc.state)
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			nDefenders, err := policy.DefenderDices(tc.state, nAttackers)
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			if nAttackers != tc.wantAttackers || nDefenders != tc.wantDefenders {
+				t.Errorf("Expected %d vs %d dices but got %d vs %d", tc.wantAttackers, tc.wantDefenders, nAttackers, nDefenders)
+			}
+		})
+	}
+
+	if _, err := policy.AttackerDices(BattleState{AttackerUnits: 6, DefenderUnits: 1}); err == nil {
+		t.Errorf("Expected states outside of the policy to fail")
+	}
+	if got := policy.DicesPolicy(AttackerSide)(BattleState{AttackerUnits: 60, DefenderUnits: 1}, 3); got != 3 {
+		t.Errorf("Expected max dices outside of the policy but got %d", got)
+	}
+	if _, err := SolveOptimalPolicy(RisiKoRules, Objective(10), BattleState{}); err == nil {
+		t.Errorf("Expected unknown objective to fail")
+	}
+}
+
+func TestOptimalPolicyStrategies(t *testing.T) {
+	policy, err := SolveOptimalPolicy(RiskRules, MaximiseExpectedUnitsLeft, BattleState{AttackerUnits: 10, DefenderUnits: 10})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	for range 100 {
+		got, err := Battle(RiskRules, BattleState{AttackerUnits: 10, DefenderUnits: 10}, policy.AttackerStrategy(FairDicesGen), policy.DefenderStrategy(FairDicesGen))
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		if !got.AttackerWon() && got.AttackerUnits >= RiskRules.MinAttackUnits {
+			t.Errorf("Unexpected final state %v", got)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package risiko
+
+import (
+	"fmt"
+	"reflect"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// Replay -> Plays a battle again with the throws of its transcript
+///////////////////////////////////////////////////////////////////////////////
+
+// Returns a generator throwing the recorded rolls one after the other. It
+// fails when asked for a different number of dices than recorded or when
+// there are no rolls left. It is not safe for concurrent use.
+func NewReplayDicesGen(rolls [][]int) DicesGenerator {
+	next := 0
+	return func(count int) (Dices, error) {
+		if next >= len(rolls) {
+			return nil, fmt.Errorf("%w: no rolls left after %d", ErrReplayMismatch, len(rolls))
+		}
+		if count != len(rolls[next]) {
+			return nil, fmt.Errorf("%w: roll %d has %d dices, asked for %d", ErrReplayMismatch, next+1, len(rolls[next]), count)
+		}
+		next++
+		return &recordedDices{throws: rolls[next-1]}, nil
+	}
+}
+
+// Throws as many dices as recorded. The attacker retreats when its rolls run
+// out, the defender fails.
+type replayStrategy struct {
+	side     Side
+	counts   []int
+	genDices DicesGenerator
+	next     int
+}
+
+func (r *replayStrategy) UpdateState(BattleState) {}
+
+func (r *replayStrategy) GetDices() (Dices, error) {
+	if r.next >= len(r.counts) {
+		if r.side == AttackerSide {
+			return nil, ErrRetreat
+		}
+		return nil, fmt.Errorf("%w: no %v rolls left", ErrReplayMismatch, r.side)
+	}
+	r.next++
+	return r.genDices(r.counts[r.next-1])
+}
+
+func newReplayStrategy(side Side, rolls [][]int) BattleStrategy {
+	return func(Rules) EngageStrategy {
+		counts := []int{}
+		for _, roll := range rolls {
+			counts = append(counts, len(roll))
+		}
+		return &replayStrategy{side: side, counts: counts, genDices: NewReplayDicesGen(rolls)}
+	}
+}
+
+// Plays the battle of the transcript again with its throws and checks every
+// round, throws, comparisons and losses included, and how the battle ended
+// match the recorded ones. Returns the final state.
+func Replay(rules Rules, transcript *Transcript) (BattleState, error) {
+	if transcript.Ending == nil {
+		return BattleState{}, fmt.Errorf("%w: transcript doesn't say how the battle ended", ErrReplayMismatch)
+	}
+	start := transcript.Ending.Final
+	if len(transcript.Rounds) > 0 {
+		start = transcript.Rounds[0].Before
+	}
+	attackerRolls := [][]int{}
+	defenderRolls := [][]int{}
+	for _, round := range transcript.Rounds {
+		attackerRolls = append(attackerRolls, round.AttackerThrows)
+		defenderRolls = append(defenderRolls, round.DefenderThrows)
+	}
+
+	replayed := &Transcript{}
+	final, err := BattleRecorded(rules, start,
+		newReplayStrategy(AttackerSide, attackerRolls),
+		newReplayStrategy(DefenderSide, defenderRolls),
+		replayed)
+	if err != nil {
+		return BattleState{}, err
+	}
+	for i, round := range replayed.Rounds {
+		if i >= len(transcript.Rounds) {
+			break
+		}
+		recorded := transcript.Rounds[i]
+		if round.Before != recorded.Before || round.After != recorded.After {
+			return BattleState{}, fmt.Errorf("%w: round %d goes from %v to %v, recorded from %v to %v", ErrReplayMismatch, i+1, round.Before, round.After, recorded.Before, recorded.After)
+		}
+		if !reflect.DeepEqual(round, recorded) {
+			return BattleState{}, fmt.Errorf("%w: round %d throws %v against %v with losses %d and %d, recorded %v against %v with losses %d and %d",
+				ErrReplayMismatch, i+1, round.AttackerThrows, round.DefenderThrows, round.AttackerLoss, round.DefenderLoss,
+				recorded.AttackerThrows, recorded.DefenderThrows, recorded.AttackerLoss, recorded.DefenderLoss)
+		}
+	}
+	if len(replayed.Rounds) != len(transcript.Rounds) {
+		return BattleState{}, fmt.Errorf("%w: battle is over after %d rounds, recorded %d", ErrReplayMismatch, len(replayed.Rounds), len(transcript.Rounds))
+	}
+	if *replayed.Ending != *transcript.Ending {
+		return BattleState{}, fmt.Errorf("%w: battle is %s at %v after %d rounds, recorded %s at %v after %d", ErrReplayMismatch,
+			replayed.Ending.End, replayed.Ending.Final, replayed.Ending.Rounds, transcript.Ending.End, transcript.Ending.Final, transcript.Ending.Rounds)
+	}
+	return final, nil
+}
@@ -0,0 +1,131 @@
+package risiko
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestReplayDicesGen(t *testing.T) {
+	gen := NewReplayDicesGen([][]int{{2, 5, 3}, {1}})
+	dices, err := gen(3)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if got := dices.Roll(); !slices.Equal(got, []int{5, 3, 2}) {
+		t.Errorf("Expected sorted roll [5 3 2] but got %v", got)
+	}
+	if _, err := gen(2); !errors.Is(err, ErrReplayMismatch) {
+		t.Errorf("Expected wrong count to mismatch but got %v", err)
+	}
+	if _, err := gen(1); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if _, err := gen(1); !errors.Is(err, ErrReplayMismatch) {
+		t.Errorf("Expected no rolls left to mismatch but got %v", err)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	recordBattle := func(t *testing.T, attacker BattleStrategy) (*Transcript, BattleState) {
+		transcript := &Transcript{}
+		final, err := BattleRecorded(RisiKoRules, BattleState{AttackerUnits: 12, DefenderUnits: 8},
+			attacker, NewMaxDefendersStrategy(NewSeededDicesGen(7, 1)), transcript)
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		return transcript, final
+	}
+
+	testCases := []struct {
+		name     string
+		attacker BattleStrategy
+	}{
+		{name: "max dices", attacker: NewMaxAttackersStrategy(NewSeededDicesGen(7, 0))},
+		{name: "capped dices", attacker: NewPolicyAttackersStrategy(NewSeededDicesGen(7, 0), NewCappedDicesPolicy(2))},
+		{name: "retreat", attacker: NewRetreatAttackersStrategy(NewSeededDicesGen(7, 0), RetreatThresholds{MinUnits: 8})},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			transcript, want := recordBattle(t, tc.attacker)
+			got, err := Replay(RisiKoRules, transcript)
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			if got != want {
+				t.Errorf("Expected replay to end at %v but got %v", want, got)
+			}
+		})
+	}
+
+	t.Run("tampered", func(t *testing.T) {
+		transcript, _ := recordBattle(t, NewMaxAttackersStrategy(NewSeededDicesGen(7, 0)))
+		transcript.Rounds[0].After.DefenderUnits++
+		if _, err := Replay(RisiKoRules, transcript); !errors.Is(err, ErrReplayMismatch) {
+			t.Errorf("Expected tampered transcript to mismatch but got %v", err)
+		}
+	})
+
+	t.Run("first rounds cut off", func(t *testing.T) {
+		transcript, _ := recordBattle(t, NewMaxAttackersStrategy(NewSeededDicesGen(7, 0)))
+		transcript.Rounds = transcript.Rounds[1:]
+		transcript.Rounds[0].DefenderThrows = append(transcript.Rounds[0].DefenderThrows, 1, 1, 1)
+		if _, err := Replay(RisiKoRules, transcript); err == nil {
+			t.Errorf("Expected invalid transcript to fail")
+		}
+	})
+
+	t.Run("last rounds cut off", func(t *testing.T) {
+		transcript, _ := recordBattle(t, NewMaxAttackersStrategy(NewSeededDicesGen(7, 0)))
+		if len(transcript.Rounds) < 3 {
+			t.Fatalf("Expected a longer battle but got %d rounds", len(transcript.Rounds))
+		}
+		transcript.Rounds = transcript.Rounds[:len(transcript.Rounds)-2]
+		// Looks like a retreat without the ending
+		if _, err := Replay(RisiKoRules, transcript); !errors.Is(err, ErrReplayMismatch) {
+			t.Errorf("Expected shortened transcript to mismatch but got %v", err)
+		}
+		transcript.Ending = nil
+		if _, err := Replay(RisiKoRules, transcript); !errors.Is(err, ErrReplayMismatch) {
+			t.Errorf("Expected transcript without ending to mismatch but got %v", err)
+		}
+	})
+
+	t.Run("different losses", func(t *testing.T) {
+		transcript, _ := recordBattle(t, NewMaxAttackersStrategy(NewSeededDicesGen(7, 0)))
+		transcript.Rounds[0].Comparisons[0].AttackerLost = !transcript.Rounds[0].Comparisons[0].AttackerLost
+		if _, err := Replay(RisiKoRules, transcript); !errors.Is(err, ErrReplayMismatch) {
+			t.Errorf("Expected different comparisons to mismatch but got %v", err)
+		}
+	})
+
+	t.Run("impossible throws", func(t *testing.T) {
+		transcript := &Transcript{
+			Rounds: []Round{{
+				Number:         1,
+				Before:         BattleState{AttackerUnits: 3, DefenderUnits: 1},
+				After:          BattleState{AttackerUnits: 3, DefenderUnits: 0},
+				AttackerThrows: []int{9, 9},
+				DefenderThrows: []int{7},
+				Comparisons:    []Comparison{{AttackerDice: 9, DefenderDice: 7, DefenderLost: true}},
+				DefenderLoss:   1,
+			}},
+			Ending: &Ending{End: EndWon, Final: BattleState{AttackerUnits: 3, DefenderUnits: 0}, Rounds: 1},
+		}
+		if _, err := Replay(RisiKoRules, transcript); !errors.Is(err, ErrInvalidRoll) {
+			t.Errorf("Expected throws of missing faces to fail but got %v", err)
+		}
+	})
+
+	t.Run("retreat before any round", func(t *testing.T) {
+		state := BattleState{AttackerUnits: 3, DefenderUnits: 5}
+		transcript := &Transcript{Ending: &Ending{End: EndRetreated, Final: state}}
+		if got, err := Replay(RisiKoRules, transcript); err != nil || got != state {
+			t.Errorf("Expected to stay at %v but got %v (%v)", state, got, err)
+		}
+	})
+
+	if _, err := Replay(RisiKoRules, &Transcript{}); !errors.Is(err, ErrReplayMismatch) {
+		t.Errorf("Expected empty transcript to mismatch but got %v", err)
+	}
+}
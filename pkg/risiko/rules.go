@@ -0,0 +1,133 @@
+package risiko
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// How a tie between an attacker and a defender dice is resolved
+type TieRule int
+
+const (
+	DefenderWinsTies TieRule = iota
+	AttackerWinsTies
+	BothLoseOnTies
+)
+
+func (t TieRule) String() string {
+	switch t {
+	case DefenderWinsTies:
+		return "defender"
+	case AttackerWinsTies:
+		return "attacker"
+	case BothLoseOnTies:
+		return "both"
+	default:
+		return fmt.Sprintf("TieRule(%d)", int(t))
+	}
+}
+
+func (t TieRule) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *TieRule) UnmarshalText(text []byte) error {
+	for _, rule := range []TieRule{DefenderWinsTies, AttackerWinsTies, BothLoseOnTies} {
+		if rule.String() == string(text) {
+			*t = rule
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: unknown tie rule %q", ErrInvalidRules, text)
+}
+
+// Rules of an engagement
+type Rules struct {
+	// Most dices thrown by the attacker at each engagement
+	MaxAttackDices int `json:"max_attack_dices"`
+	// Most dices thrown by the defender at each engagement
+	MaxDefenceDices int `json:"max_defence_dices"`
+	// Units the attacker needs to be able to attack
+	MinAttackUnits int `json:"min_attack_units"`
+	// Units of the attacker that must stay behind and cannot throw dices
+	UnitsStayBehind int     `json:"units_stay_behind"`
+	Ties            TieRule `json:"ties"`
+	// Dices thrown by each side, fair six sided dices when nil. Battles throw
+	// them for random dices built without a dice, e.g. by FairDicesGen, and
+	// reject any other roll outside their faces.
+	AttackDice  *Dice `json:"attack_dice,omitempty"`
+	DefenceDice *Dice `json:"defence_dice,omitempty"`
+}
+
+// Rules of RisiKo!, three dices per side and defender wins ties
+var RisiKoRules = Rules{
+	MaxAttackDices:  3,
+	MaxDefenceDices: 3,
+	MinAttackUnits:  2,
+	UnitsStayBehind: 1,
+	Ties:            DefenderWinsTies,
+}
+
+// Rules of classic Hasbro Risk, three attack dices against two defence dices
+// and defender wins ties
+var RiskRules = Rules{
+	MaxAttackDices:  3,
+	MaxDefenceDices: 2,
+	MinAttackUnits:  2,
+	UnitsStayBehind: 1,
+	Ties:            DefenderWinsTies,
+}
+
+var rulesPresets = map[string]Rules{
+	"risiko": RisiKoRules,
+	"risk":   RiskRules,
+}
+
+// Returns the built-in rules with the given name, see RulesPresetNames
+func RulesPreset(name string) (Rules, error) {
+	rules, ok := rulesPresets[name]
+	if !ok {
+		return Rules{}, fmt.Errorf("%w: unknown rules %q, want one of %v", ErrInvalidRules, name, RulesPresetNames())
+	}
+	return rules, nil
+}
+
+// Names of the built-in rules in alphabetical order
+func RulesPresetNames() []string {
+	return slices.Sorted(maps.Keys(rulesPresets))
+}
+
+// Checks that a battle can be played with the rules
+func (r Rules) Validate() error {
+	if r.MaxAttackDices < 1 || r.MaxDefenceDices < 1 {
+		return fmt.Errorf("%w: both sides need to throw at least one dice, got %d attack and %d defence dices", ErrInvalidRules, r.MaxAttackDices, r.MaxDefenceDices)
+	}
+	if r.UnitsStayBehind < 0 {
+		return fmt.Errorf("%w: units staying behind cannot be negative, got %d", ErrInvalidRules, r.UnitsStayBehind)
+	}
+	if r.MinAttackUnits <= r.UnitsStayBehind {
+		return fmt.Errorf("%w: attacking with %d units leaves no dices to throw when %d must stay behind", ErrInvalidRules, r.MinAttackUnits, r.UnitsStayBehind)
+	}
+	if r.Ties < DefenderWinsTies || r.Ties > BothLoseOnTies {
+		return fmt.Errorf("%w: unknown tie rule %v", ErrInvalidRules, r.Ties)
+	}
+	for _, dice := range []*Dice{r.AttackDice, r.DefenceDice} {
+		if dice != nil && dice.Faces() == 0 {
+			return fmt.Errorf("%w: %w: dices must be created with NewDice or NewFairDice", ErrInvalidRules, ErrInvalidDice)
+		}
+	}
+	return nil
+}
+
+// Dice thrown by the side, the fair six sided one when the rules don't set it
+func (r Rules) Dice(side Side) *Dice {
+	dice := r.DefenceDice
+	if side == AttackerSide {
+		dice = r.AttackDice
+	}
+	if dice == nil {
+		return sixSidedDice
+	}
+	return dice
+}
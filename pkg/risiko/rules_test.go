@@ -0,0 +1,123 @@
+package risiko
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestRulesValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		rules   Rules
+		wantErr bool
+	}{
+		{name: "risiko", rules: RisiKoRules},
+		{name: "no units behind", rules: Rules{MaxAttackDices: 3, MaxDefenceDices: 2, MinAttackUnits: 1, Ties: AttackerWinsTies}},
+		{name: "zero value", rules: Rules{}, wantErr: true},
+		{name: "no defence dices", rules: Rules{MaxAttackDices: 3, MinAttackUnits: 2, UnitsStayBehind: 1}, wantErr: true},
+		{name: "everyone stays behind", rules: Rules{MaxAttackDices: 3, MaxDefenceDices: 3, MinAttackUnits: 2, UnitsStayBehind: 2}, wantErr: true},
+		{name: "unknown ties", rules: Rules{MaxAttackDices: 3, MaxDefenceDices: 3, MinAttackUnits: 2, UnitsStayBehind: 1, Ties: 10}, wantErr: true},
+		{name: "eight sided attacker", rules: Rules{MaxAttackDices: 3, MaxDefenceDices: 3, MinAttackUnits: 2, UnitsStayBehind: 1, AttackDice: mustNewFairDice(8)}},
+		{name: "dice without faces", rules: Rules{MaxAttackDices: 3, MaxDefenceDices: 3, MinAttackUnits: 2, UnitsStayBehind: 1, DefenceDice: &Dice{}}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.rules.Validate()
+			if tc.wantErr && err == nil {
+				t.Errorf("Expected rules to be invalid")
+			} else if !tc.wantErr && err != nil {
+				t.Errorf("Unexpected error %v", err)
+			}
+		})
+	}
+}
+
+func TestRulesPreset(t *testing.T) {
+	for _, name := range RulesPresetNames() {
+		rules, err := RulesPreset(name)
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+		if err := rules.Validate(); err != nil {
+			t.Errorf("Expected %s rules to be valid but got %v", name, err)
+		}
+	}
+	if rules, err := RulesPreset("risk"); err != nil || rules != RiskRules {
+		t.Errorf("Expected risk rules but got %+v (%v)", rules, err)
+	}
+	if _, err := RulesPreset("monopoly"); err == nil {
+		t.Errorf("Expected unknown rules to fail")
+	}
+}
+
+// Published odds of a single Risk engagement, out of 6^(dices thrown)
+func TestRiskEngageOdds(t *testing.T) {
+	solver, err := NewSolver(RiskRules)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	testCases := []struct {
+		nAttackers int
+		nDefenders int
+		// Probability of each attacker loss, defender loss pair
+		want map[[2]int]float64
+	}{
+		{nAttackers: 1, nDefenders: 1, want: map[[2]int]float64{{0, 1}: 15.0 / 36, {1, 0}: 21.0 / 36}},
+		{nAttackers: 3, nDefenders: 1, want: map[[2]int]float64{{0, 1}: 855.0 / 1296, {1, 0}: 441.0 / 1296}},
+		{nAttackers: 2, nDefenders: 2, want: map[[2]int]float64{{0, 2}: 295.0 / 1296, {1, 1}: 420.0 / 1296, {2, 0}: 581.0 / 1296}},
+		{nAttackers: 3, nDefenders: 2, want: map[[2]int]float64{{0, 2}: 2890.0 / 7776, {1, 1}: 2611.0 / 7776, {2, 0}: 2275.0 / 7776}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%dv%d", tc.nAttackers, tc.nDefenders), func(t *testing.T) {
+			outcomes := solver.engageOutcomes(tc.nAttackers, tc.nDefenders)
+			if len(outcomes) != len(tc.want) {
+				t.Errorf("Expected %d outcomes but got %d", len(tc.want), len(outcomes))
+			}
+			for _, outcome := range outcomes {
+				want := tc.want[[2]int{outcome.attackerLoss, outcome.defenderLoss}]
+				if math.Abs(outcome.probability-want) > 1e-12 {
+					t.Errorf("Expected probability %f of losses %d-%d but got %f", want, outcome.attackerLoss, outcome.defenderLoss, outcome.probability)
+				}
+			}
+		})
+	}
+}
+
+// Published Risk odds of winning a battle with the given attacking units,
+// which excludes the one staying behind, against the given defenders. From
+// J. A. Osborne, "Markov Chains for the RISK Board Game Revisited", 2003.
+func TestRiskBattleOdds(t *testing.T) {
+	solver, err := NewSolver(RiskRules)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	testCases := []struct {
+		nAttackers int
+		nDefenders int
+		want       float64
+	}{
+		{nAttackers: 1, nDefenders: 1, want: 0.417},
+		{nAttackers: 2, nDefenders: 1, want: 0.754},
+		{nAttackers: 1, nDefenders: 2, want: 0.106},
+		{nAttackers: 2, nDefenders: 2, want: 0.363},
+		{nAttackers: 3, nDefenders: 2, want: 0.656},
+		{nAttackers: 3, nDefenders: 3, want: 0.470},
+		{nAttackers: 5, nDefenders: 5, want: 0.506},
+		{nAttackers: 10, nDefenders: 5, want: 0.916},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%dv%d", tc.nAttackers, tc.nDefenders), func(t *testing.T) {
+			dist, err := solver.Solve(BattleState{AttackerUnits: tc.nAttackers + RiskRules.UnitsStayBehind, DefenderUnits: tc.nDefenders})
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			if got := dist.WinProbability(); math.Abs(got-tc.want) > 0.0005 {
+				t.Errorf("Expected win probability %.3f but got %.3f", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,450 @@
+package risiko
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Battles run by a worker before merging them into the sweep
+const simulateBatchSize = 1000
+
+// Returned alongside the partial sweep when a simulation is interrupted. It
+// wraps the reason the context was done.
+var ErrIncompleteSimulation = errors.New("simulation incomplete")
+
+// Snapshot of a running simulation
+type Progress struct {
+	CellsDone  int
+	CellsTotal int
+	BattlesRun int
+	Elapsed    time.Duration
+	// Estimated time left, zero until there is enough data to estimate it
+	ETA time.Duration
+}
+
+type SimulationResult struct {
+	NRuns                  int
+	NAttackerWon           int
+	TotalAttackerUnitsLeft int
+	// Sum of the squared attacker units left, used to estimate the variance
+	TotalSquaredAttackerUnitsLeft int
+	// How many runs ended in each final state
+	FinalStates map[BattleState]int
+	// Units moved into the conquered territories, only set WithConquest
+	TotalUnitsMoved int
+}
+
+type SimulationSweep = map[int]map[int]SimulationResult
+
+func (r SimulationResult) WinProbability() float64 {
+	return float64(r.NAttackerWon) / float64(r.NRuns)
+}
+
+func (r SimulationResult) ExpectedAttackerUnitsLeft() float64 {
+	return float64(r.TotalAttackerUnitsLeft) / float64(r.NRuns)
+}
+
+// Without final states it assumes the attacker is left with a single unit
+// whenever it loses, which doesn't hold when it retreats
+func (r SimulationResult) AttackerUnitsLeftOnWin() float64 {
+	if r.FinalStates == nil {
+		return float64(r.TotalAttackerUnitsLeft-(r.NRuns-r.NAttackerWon)) / float64(r.NAttackerWon)
+	}
+	unitsLeft := 0
+	for state, count := range r.FinalStates {
+		if state.AttackerWon() {
+			unitsLeft += state.AttackerUnits * count
+		}
+	}
+	return float64(unitsLeft) / float64(r.NAttackerWon)
+}
+
+// Confidence interval of the win rate using the Wilson score interval
+func (r SimulationResult) WinRateWilson(confidence float64) (float64, float64, error) {
+	return wilsonInterval(r.NAttackerWon, r.NRuns, confidence)
+}
+
+// Confidence interval of the win rate using the exact Clopper-Pearson interval
+func (r SimulationResult) WinRateClopperPearson(confidence float64) (float64, float64, error) {
+	return clopperPearsonInterval(r.NAttackerWon, r.NRuns, confidence)
+}
+
+// Sample variance of the attacker units left
+func (r SimulationResult) AttackerUnitsLeftVariance() float64 {
+	if r.NRuns < 2 {
+		return math.NaN()
+	}
+	n := float64(r.NRuns)
+	mean := float64(r.TotalAttackerUnitsLeft) / n
+	return (float64(r.TotalSquaredAttackerUnitsLeft) - n*mean*mean) / (n - 1)
+}
+
+// Standard error of ExpectedAttackerUnitsLeft
+func (r SimulationResult) ExpectedAttackerUnitsLeftStdErr() float64 {
+	return math.Sqrt(r.AttackerUnitsLeftVariance() / float64(r.NRuns))
+}
+
+// Average units moved into the conquered territory when the attacker wins,
+// only meaningful for simulations run WithConquest
+func (r SimulationResult) UnitsMovedOnWin() float64 {
+	return float64(r.TotalUnitsMoved) / float64(r.NAttackerWon)
+}
+
+// Empirical probability of each final state
+func (r SimulationResult) Distribution() Distribution {
+	dist := Distribution{}
+	for state, count := range r.FinalStates {
+		dist[state] = float64(count) / float64(r.NRuns)
+	}
+	return dist
+}
+
+// Records the result of one more battle
+func (r *SimulationResult) add(result BattleResult) {
+	final := result.Final
+	if r.FinalStates == nil {
+		r.FinalStates = map[BattleState]int{}
+	}
+	r.NRuns++
+	if final.AttackerWon() {
+		r.NAttackerWon++
+	}
+	r.TotalAttackerUnitsLeft += final.AttackerUnits
+	r.TotalSquaredAttackerUnitsLeft += final.AttackerUnits * final.AttackerUnits
+	r.FinalStates[final]++
+	if result.Conquest != nil {
+		r.TotalUnitsMoved += result.Conquest.UnitsMoved
+	}
+}
+
+// Accumulates the battles of other into r
+func (r *SimulationResult) merge(other SimulationResult) {
+	if r.FinalStates == nil {
+		r.FinalStates = map[BattleState]int{}
+	}
+	r.NRuns += other.NRuns
+	r.NAttackerWon += other.NAttackerWon
+	r.TotalAttackerUnitsLeft += other.TotalAttackerUnitsLeft
+	r.TotalSquaredAttackerUnitsLeft += other.TotalSquaredAttackerUnitsLeft
+	r.TotalUnitsMoved += other.TotalUnitsMoved
+	for state, count := range other.FinalStates {
+		r.FinalStates[state] += count
+	}
+}
+
+// Configures Simulate
+type SimulateOption func(*simulateConfig)
+
+type simulateConfig struct {
+	rules           Rules
+	workers         int
+	progress        func(Progress)
+	conquest        ConquestStrategy
+	targetHalfWidth float64
+	confidence      float64
+	maxRuns         int
+	// Square sweep when nil
+	matchups Matchups
+}
+
+// Rules of the simulated battles. Defaults to RisiKoRules.
+func WithRules(rules Rules) SimulateOption {
+	return func(c *simulateConfig) {
+		c.rules = rules
+	}
+}
+
+// Number of goroutines running battles. Defaults to GOMAXPROCS, values below 1
+// fall back to the default.
+func WithWorkers(n int) SimulateOption {
+	return func(c *simulateConfig) {
+		c.workers = n
+	}
+}
+
+// Calls fn after every batch of battles is merged into the sweep. Calls are
+// never concurrent.
+func WithProgress(fn func(Progress)) SimulateOption {
+	return func(c *simulateConfig) {
+		c.progress = fn
+	}
+}
+
+// Moves the attacker armies into the conquered territory after every battle
+// won, tracking the units moved in SimulationResult.TotalUnitsMoved
+func WithConquest(strategy ConquestStrategy) SimulateOption {
+	return func(c *simulateConfig) {
+		c.conquest = strategy
+	}
+}
+
+// Simulates only the given battles instead of the square sweep, e.g. from
+// GridMatchups. nUnitsSweep is ignored.
+func WithMatchups(matchups Matchups) SimulateOption {
+	return func(c *simulateConfig) {
+		c.matchups = matchups
+	}
+}
+
+// Keeps sampling each cell in batches of nRuns until the confidence interval
+// of the win rate is no wider than halfWidth on each side, or maxRuns battles
+// have been run for that cell
+func WithTargetPrecision(halfWidth float64, confidence float64, maxRuns int) SimulateOption {
+	return func(c *simulateConfig) {
+		c.targetHalfWidth = halfWidth
+		c.confidence = confidence
+		c.maxRuns = maxRuns
+	}
+}
+
+// Whether a cell with the given results needs more runs
+func (c *simulateConfig) needsMoreRuns(result SimulationResult) bool {
+	if c.maxRuns <= 0 || result.NRuns >= c.maxRuns {
+		return false
+	}
+	low, high, err := wilsonInterval(result.NAttackerWon, result.NRuns, c.confidence)
+	if err != nil {
+		return false
+	}
+	return (high-low)/2 > c.targetHalfWidth
+}
+
+// Battles of a cell run by a worker since its last batch
+type cellBatch struct {
+	initialState BattleState
+	result       SimulationResult
+	// Last batch of the cell
+	done bool
+}
+
+// Builds a battle strategy throwing the dices of gen, e.g.
+// NewMaxAttackersStrategy
+type StrategyFactory = func(gen DicesGenerator) BattleStrategy
+
+// Runs nRuns battles for every attackers/defenders pair up to nUnitsSweep,
+// spreading the cells over a pool of workers. When ctx is done before the end
+// it returns the cells simulated so far along with ErrIncompleteSimulation.
+func Simulate(ctx context.Context, nRuns int, nUnitsSweep int, attackerStrategy BattleStrategy, defenderStrategy BattleStrategy, opts ...SimulateOption) (SimulationSweep, error) {
+	config, err := newSimulateConfig(nRuns, opts)
+	if err != nil {
+		return nil, err
+	}
+	newWorker := func() cellStrategies {
+		return func(BattleState) (BattleStrategy, BattleStrategy) {
+			return attackerStrategy, defenderStrategy
+		}
+	}
+	return config.simulate(ctx, nRuns, nUnitsSweep, newWorker)
+}
+
+// Like Simulate, but each side of each cell throws dices from its own stream
+// derived from seed. Results only depend on the seed, regardless of the number
+// of workers and how they are scheduled.
+func SimulateSeeded(ctx context.Context, seed uint64, nRuns int, nUnitsSweep int, attackerStrategy StrategyFactory, defenderStrategy StrategyFactory, opts ...SimulateOption) (SimulationSweep, error) {
+	config, err := newSimulateConfig(nRuns, opts)
+	if err != nil {
+		return nil, err
+	}
+	newWorker := func() cellStrategies {
+		// Sources are reused across the cells of a worker and moved to the
+		// streams of the cell it's simulating
+		attackerSrc, defenderSrc := &rand.PCG{}, &rand.PCG{}
+		attacker := attackerStrategy(NewCustomDicesGen(attackerSrc, config.rules.AttackDice))
+		defender := defenderStrategy(NewCustomDicesGen(defenderSrc, config.rules.DefenceDice))
+		return func(cell BattleState) (BattleStrategy, BattleStrategy) {
+			seedStream(attackerSrc, seed, cellStream(cell, 0))
+			seedStream(defenderSrc, seed, cellStream(cell, 1))
+			return attacker, defender
+		}
+	}
+	return config.simulate(ctx, nRuns, nUnitsSweep, newWorker)
+}
+
+// Identifies the dices stream of one side of a cell
+func cellStream(cell BattleState, side uint64) uint64 {
+	return uint64(cell.AttackerUnits)<<33 | uint64(cell.DefenderUnits)<<1 | side
+}
+
+func newSimulateConfig(nRuns int, opts []SimulateOption) (*simulateConfig, error) {
+	config := &simulateConfig{rules: RisiKoRules}
+	for _, opt := range opts {
+		opt(config)
+	}
+	if err := config.rules.Validate(); err != nil {
+		return nil, err
+	}
+	if config.workers < 1 {
+		config.workers = runtime.GOMAXPROCS(0)
+	}
+	if err := config.matchups.Validate(); err != nil {
+		return nil, err
+	}
+	if config.maxRuns > 0 {
+		if _, err := zScore(config.confidence); err != nil {
+			return nil, err
+		}
+		if config.targetHalfWidth <= 0 {
+			return nil, fmt.Errorf("%w: target half width must be positive, got %f", ErrInvalidOption, config.targetHalfWidth)
+		}
+		if nRuns <= 0 {
+			return nil, fmt.Errorf("%w: runs per batch must be positive, got %d", ErrInvalidOption, nRuns)
+		}
+	}
+	return config, nil
+}
+
+// Returns the attacker and defender strategies of a cell
+type cellStrategies = func(BattleState) (BattleStrategy, BattleStrategy)
+
+// Runs the sweep calling newWorker once per worker to get the strategies of
+// the cells it simulates, so that they are never shared across goroutines
+func (c *simulateConfig) simulate(ctx context.Context, nRuns int, nUnitsSweep int, newWorker func() cellStrategies) (SimulationSweep, error) {
+	parentCtx := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	matchups := c.matchups
+	if matchups == nil {
+		matchups = SquareMatchups(c.rules, nUnitsSweep)
+	}
+	// Repeated cells would be simulated twice and merged
+	matchups = matchups.unique()
+
+	cells := make(chan BattleState)
+	batches := make(chan cellBatch)
+	// Each worker reports at most one error so it never blocks on it
+	errs := make(chan error, c.workers)
+
+	go func() {
+		defer close(cells)
+		for _, cell := range matchups {
+			select {
+			case cells <- cell:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for range c.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			strategies := newWorker()
+			for cell := range cells {
+				attackerStrategy, defenderStrategy := strategies(cell)
+				if err := c.simulateCell(ctx, cell, nRuns, attackerStrategy, defenderStrategy, batches); err != nil {
+					if ctx.Err() == nil {
+						errs <- err
+						cancel()
+					}
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(batches)
+	}()
+
+	progress := Progress{CellsTotal: len(matchups)}
+	start := time.Now()
+	simResult := SimulationSweep{}
+	for batch := range batches {
+		state := batch.initialState
+		if _, ok := simResult[state.AttackerUnits]; !ok {
+			simResult[state.AttackerUnits] = map[int]SimulationResult{}
+		}
+		cell := simResult[state.AttackerUnits][state.DefenderUnits]
+		cell.merge(batch.result)
+		simResult[state.AttackerUnits][state.DefenderUnits] = cell
+
+		progress.BattlesRun += batch.result.NRuns
+		if batch.done {
+			progress.CellsDone++
+		}
+		if c.progress != nil {
+			progress.Elapsed = time.Since(start)
+			progress.ETA = c.estimateTimeLeft(progress, nRuns)
+			c.progress(progress)
+		}
+	}
+
+	select {
+	case err := <-errs:
+		return nil, err
+	default:
+	}
+	if progress.CellsDone < progress.CellsTotal {
+		return simResult, fmt.Errorf("%w: %w", ErrIncompleteSimulation, context.Cause(parentCtx))
+	}
+	return simResult, nil
+}
+
+// Extrapolates the time left from the battles run so far or, when the number
+// of battles per cell is not known upfront, from the cells done
+func (c *simulateConfig) estimateTimeLeft(progress Progress, nRuns int) time.Duration {
+	done, total := float64(progress.BattlesRun), float64(nRuns*progress.CellsTotal)
+	if c.maxRuns > 0 {
+		done, total = float64(progress.CellsDone), float64(progress.CellsTotal)
+	}
+	if done <= 0 {
+		return 0
+	}
+	return time.Duration(float64(progress.Elapsed) * (total - done) / done)
+}
+
+// Runs the battles of a single cell, sending them in batches
+func (c *simulateConfig) simulateCell(ctx context.Context, initialState BattleState, nRuns int, attackerStrategy BattleStrategy, defenderStrategy BattleStrategy, batches chan<- cellBatch) error {
+	cell := SimulationResult{}
+	for {
+		roundRuns := nRuns
+		if c.maxRuns > 0 {
+			roundRuns = min(nRuns, c.maxRuns-cell.NRuns)
+		}
+		for {
+			batchRuns := max(0, min(roundRuns, simulateBatchSize))
+			roundRuns -= batchRuns
+
+			batch := cellBatch{initialState: initialState}
+			for range batchRuns {
+				result, err := c.battle(initialState, attackerStrategy, defenderStrategy)
+				if err != nil {
+					return err
+				}
+				batch.result.add(result)
+			}
+			cell.merge(batch.result)
+			batch.done = roundRuns <= 0 && !c.needsMoreRuns(cell)
+
+			select {
+			case batches <- batch:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			if batch.done {
+				return nil
+			}
+			if roundRuns <= 0 {
+				break
+			}
+		}
+	}
+}
+
+// Plays a battle of the simulation, conquering the territory if configured to
+func (c *simulateConfig) battle(initialState BattleState, attackerStrategy BattleStrategy, defenderStrategy BattleStrategy) (BattleResult, error) {
+	if c.conquest != nil {
+		return BattleAndConquer(c.rules, initialState, attackerStrategy, defenderStrategy, c.conquest)
+	}
+	final, err := Battle(c.rules, initialState, attackerStrategy, defenderStrategy)
+	return BattleResult{Final: final}, err
+}
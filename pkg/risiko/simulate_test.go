@@ -0,0 +1,264 @@
+package risiko
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestSimulate(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name        string
+		attacker    BattleStrategy
+		defender    BattleStrategy
+		nUnitsSweep int
+		nRuns       int
+	}{
+		{
+			name:        "normal cheater",
+			attacker:    NewMaxAttackersStrategy(createTestSingleSidedDicesGen(6)),
+			defender:    NewMaxDefendersStrategy(createTestSingleSidedDicesGen(6)),
+			nUnitsSweep: 3,
+			nRuns:       5,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Simulate(ctx, tc.nRuns, tc.nUnitsSweep, tc.attacker, tc.defender)
+			if err != nil {
+				t.Errorf("Unexpected error %v", err)
+			}
+
+			for a := RisiKoRules.MinAttackUnits; a <= tc.nUnitsSweep; a++ {
+				for d := 1; d <= tc.nUnitsSweep; d++ {
+					if _, ok := result[a]; !ok {
+						t.Errorf("unexpected empty object for %d attackers", a)
+					} else if got, ok := result[a][d]; !ok {
+						t.Errorf("unexpected empty object for %d defenders", d)
+					} else if got.NRuns != tc.nRuns {
+						t.Errorf("unexpected n of runs for %d attackers and %d defenders. Got %d and wanted %d", a, d, got.NRuns, tc.nRuns)
+					}
+					if result[a][d].NAttackerWon < 0 {
+						t.Errorf("impossible that attackers won is less than 0, but got %d", result[a][d].NAttackerWon)
+					}
+					if result[a][d].TotalAttackerUnitsLeft < 0 {
+						t.Errorf("impossible that total attacker units left are less than 0, but got %d", result[a][d].TotalAttackerUnitsLeft)
+					}
+					nFinalStates := 0
+					for _, count := range result[a][d].FinalStates {
+						nFinalStates += count
+					}
+					if nFinalStates != result[a][d].NRuns {
+						t.Errorf("unexpected n of final states for %d attackers and %d defenders. Got %d and wanted %d", a, d, nFinalStates, result[a][d].NRuns)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSimulateAdaptive(t *testing.T) {
+	ctx := context.Background()
+	nUnitsSweep := 4
+	halfWidth := 0.05
+	maxRuns := 1000
+	result, err := Simulate(ctx, 100, nUnitsSweep, NewMaxAttackersStrategy(FairDicesGen), NewMaxDefendersStrategy(FairDicesGen), WithTargetPrecision(halfWidth, 0.95, maxRuns))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	for a := RisiKoRules.MinAttackUnits; a <= nUnitsSweep; a++ {
+		for d := 1; d <= nUnitsSweep; d++ {
+			got := result[a][d]
+			if got.NRuns > maxRuns {
+				t.Errorf("unexpected n of runs for %d attackers and %d defenders. Got %d and wanted at most %d", a, d, got.NRuns, maxRuns)
+			}
+			low, high, err := got.WinRateWilson(0.95)
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			if got.NRuns < maxRuns && (high-low)/2 > halfWidth {
+				t.Errorf("stopped before converging for %d attackers and %d defenders with %d runs", a, d, got.NRuns)
+			}
+		}
+	}
+
+	if _, err := Simulate(ctx, 100, nUnitsSweep, NewMaxAttackersStrategy(FairDicesGen), NewMaxDefendersStrategy(FairDicesGen), WithTargetPrecision(0, 0.95, maxRuns)); err == nil {
+		t.Errorf("Expected a zero half width to fail")
+	}
+}
+
+func TestSimulateWorkers(t *testing.T) {
+	ctx := context.Background()
+	nRuns := 2500
+	nUnitsSweep := 4
+	for _, workers := range []int{0, 1, 3, 100} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			result, err := Simulate(ctx, nRuns, nUnitsSweep, NewMaxAttackersStrategy(FairDicesGen), NewMaxDefendersStrategy(FairDicesGen), WithWorkers(workers))
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			for a := RisiKoRules.MinAttackUnits; a <= nUnitsSweep; a++ {
+				for d := 1; d <= nUnitsSweep; d++ {
+					if got := result[a][d].NRuns; got != nRuns {
+						t.Errorf("unexpected n of runs for %d attackers and %d defenders. Got %d and wanted %d", a, d, got, nRuns)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSimulateError(t *testing.T) {
+	brokenDicesGen := func(count int) (Dices, error) {
+		return nil, fmt.Errorf("broken dices")
+	}
+	_, err := Simulate(context.Background(), 100, 10, NewMaxAttackersStrategy(brokenDicesGen), NewMaxDefendersStrategy(FairDicesGen), WithWorkers(4))
+	if err == nil {
+		t.Errorf("Expected simulation to fail")
+	}
+}
+
+func TestSimulateCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err := Simulate(ctx, 1000000, 100, NewMaxAttackersStrategy(FairDicesGen), NewMaxDefendersStrategy(FairDicesGen))
+	if !errors.Is(err, ErrIncompleteSimulation) {
+		t.Errorf("Expected incomplete simulation error but got %v", err)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected error to wrap the context error but got %v", err)
+	}
+	if result == nil {
+		t.Errorf("Expected partial results")
+	}
+}
+
+func TestSimulateProgress(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	nRuns := 1500
+	nUnitsSweep := 5
+	var last Progress
+	nCalls := 0
+	_, err := Simulate(ctx, nRuns, nUnitsSweep, NewMaxAttackersStrategy(FairDicesGen), NewMaxDefendersStrategy(FairDicesGen), WithProgress(func(p Progress) {
+		if p.CellsDone < last.CellsDone || p.BattlesRun < last.BattlesRun {
+			t.Errorf("Progress went backwards from %+v to %+v", last, p)
+		}
+		last = p
+		nCalls++
+	}))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	nCells := (nUnitsSweep - (RisiKoRules.MinAttackUnits - 1)) * nUnitsSweep
+	if last.CellsDone != nCells || last.CellsTotal != nCells {
+		t.Errorf("Expected %d cells done but got %d out of %d", nCells, last.CellsDone, last.CellsTotal)
+	}
+	if last.BattlesRun != nRuns*nCells {
+		t.Errorf("Expected %d battles but got %d", nRuns*nCells, last.BattlesRun)
+	}
+	if last.ETA != 0 {
+		t.Errorf("Expected no time left but got %v", last.ETA)
+	}
+	if nCalls < nCells {
+		t.Errorf("Expected at least a call per cell but got %d", nCalls)
+	}
+}
+
+func TestSimulateSeeded(t *testing.T) {
+	ctx := context.Background()
+	simulate := func(seed uint64, workers int) SimulationSweep {
+		result, err := SimulateSeeded(ctx, seed, 1500, 5, NewMaxAttackersStrategy, NewMaxDefendersStrategy, WithWorkers(workers))
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		return result
+	}
+
+	want := simulate(1, 1)
+	if got := simulate(1, 8); !reflect.DeepEqual(want, got) {
+		t.Errorf("Expected the same seed to give the same results regardless of workers")
+	}
+	if got := simulate(2, 1); reflect.DeepEqual(want, got) {
+		t.Errorf("Expected different seeds to give different results")
+	}
+}
+
+func BenchmarkSimulate(b *testing.B) {
+	ctx := context.Background()
+	b.Run("global", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			if _, err := Simulate(ctx, 1000, 10, NewMaxAttackersStrategy(FairDicesGen), NewMaxDefendersStrategy(FairDicesGen)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("seeded", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := range b.N {
+			if _, err := SimulateSeeded(ctx, uint64(i), 1000, 10, NewMaxAttackersStrategy, NewMaxDefendersStrategy); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestSimulationResultUnitsLeftOnWin(t *testing.T) {
+	// Attacker retreated with 4 units once and won with 5 units twice
+	result := SimulationResult{
+		NRuns:                  3,
+		NAttackerWon:           2,
+		TotalAttackerUnitsLeft: 14,
+		FinalStates: map[BattleState]int{
+			{AttackerUnits: 4, DefenderUnits: 2}: 1,
+			{AttackerUnits: 5, DefenderUnits: 0}: 2,
+		},
+	}
+	if got := result.AttackerUnitsLeftOnWin(); got != 5 {
+		t.Errorf("Expected 5 units left on win but got %f", got)
+	}
+}
+
+func TestSimulateMatchups(t *testing.T) {
+	nRuns := 10
+	matchups := Matchups{
+		{AttackerUnits: 30, DefenderUnits: 4},
+		{AttackerUnits: 5, DefenderUnits: 12},
+		{AttackerUnits: 30, DefenderUnits: 4},
+	}
+	result, err := SimulateSeeded(context.Background(), 1, nRuns, 0, NewMaxAttackersStrategy, NewMaxDefendersStrategy, WithMatchups(matchups))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	nCells := 0
+	for a := range result {
+		for d := range result[a] {
+			nCells++
+			if got := result[a][d].NRuns; got != nRuns {
+				t.Errorf("Expected %d runs for %d attackers and %d defenders but got %d", nRuns, a, d, got)
+			}
+		}
+	}
+	if nCells != 2 {
+		t.Errorf("Expected 2 cells but got %d", nCells)
+	}
+
+	exact, err := SolveMatchups(RisiKoRules, matchups)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(exact[30]) != 1 || len(exact[5]) != 1 {
+		t.Errorf("Expected only the given matchups to be solved but got %v", exact)
+	}
+
+	invalid := WithMatchups(Matchups{{AttackerUnits: -1, DefenderUnits: 1}})
+	if _, err := Simulate(context.Background(), nRuns, 0, NewMaxAttackersStrategy(FairDicesGen), NewMaxDefendersStrategy(FairDicesGen), invalid); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("Expected negative units to fail but got %v", err)
+	}
+}
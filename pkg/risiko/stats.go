@@ -0,0 +1,131 @@
+package risiko
+
+import (
+	"fmt"
+	"math"
+)
+
+// Two sided normal quantile for the given confidence, e.g. 1.96 for 0.95
+func zScore(confidence float64) (float64, error) {
+	if confidence <= 0 || confidence >= 1 {
+		return 0, fmt.Errorf("%w: confidence must be between 0 and 1, got %f", ErrInvalidOption, confidence)
+	}
+	return math.Sqrt2 * math.Erfinv(confidence), nil
+}
+
+// Wilson score interval of nSuccess successes out of n trials
+func wilsonInterval(nSuccess int, n int, confidence float64) (float64, float64, error) {
+	z, err := zScore(confidence)
+	if err != nil {
+		return 0, 0, err
+	}
+	if n <= 0 {
+		return 0, 1, nil
+	}
+	p := float64(nSuccess) / float64(n)
+	nf := float64(n)
+	denominator := 1 + z*z/nf
+	centre := (p + z*z/(2*nf)) / denominator
+	halfWidth := z / denominator * math.Sqrt(p*(1-p)/nf+z*z/(4*nf*nf))
+	return max(0, centre-halfWidth), min(1, centre+halfWidth), nil
+}
+
+// Exact Clopper-Pearson interval of nSuccess successes out of n trials
+func clopperPearsonInterval(nSuccess int, n int, confidence float64) (float64, float64, error) {
+	if confidence <= 0 || confidence >= 1 {
+		return 0, 0, fmt.Errorf("%w: confidence must be between 0 and 1, got %f", ErrInvalidOption, confidence)
+	}
+	if n <= 0 {
+		return 0, 1, nil
+	}
+	alpha := 1 - confidence
+	k := float64(nSuccess)
+	nf := float64(n)
+	low, high := 0.0, 1.0
+	if nSuccess > 0 {
+		low = betaQuantile(alpha/2, k, nf-k+1)
+	}
+	if nSuccess < n {
+		high = betaQuantile(1-alpha/2, k+1, nf-k)
+	}
+	return low, high, nil
+}
+
+// Inverts the regularized incomplete beta function by bisection
+func betaQuantile(p float64, a float64, b float64) float64 {
+	low, high := 0.0, 1.0
+	for range 100 {
+		mid := (low + high) / 2
+		if regularizedBeta(mid, a, b) < p {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return (low + high) / 2
+}
+
+// Regularized incomplete beta function I_x(a, b)
+func regularizedBeta(x float64, a float64, b float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	lga, _ := math.Lgamma(a)
+	lgb, _ := math.Lgamma(b)
+	lgab, _ := math.Lgamma(a + b)
+	front := math.Exp(lgab - lga - lgb + a*math.Log(x) + b*math.Log(1-x))
+	// The continued fraction converges quickly only on one side of the mean
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(x, a, b) / a
+	}
+	return 1 - front*betaContinuedFraction(1-x, b, a)/b
+}
+
+// Evaluates the continued fraction of the incomplete beta function with the
+// modified Lentz method
+func betaContinuedFraction(x float64, a float64, b float64) float64 {
+	const epsilon = 1e-15
+	const tiny = 1e-300
+	c := 1.0
+	d := 1 - (a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+	for m := 1; m <= 300; m++ {
+		mf := float64(m)
+		// Even step
+		numerator := mf * (b - mf) * x / ((a + 2*mf - 1) * (a + 2*mf))
+		d = 1 + numerator*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + numerator/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		h *= d * c
+		// Odd step
+		numerator = -(a + mf) * (a + b + mf) * x / ((a + 2*mf) * (a + 2*mf + 1))
+		d = 1 + numerator*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + numerator/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return h
+}
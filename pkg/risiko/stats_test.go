@@ -0,0 +1,54 @@
+package risiko
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConfidenceIntervals(t *testing.T) {
+	testCases := []struct {
+		name     string
+		interval func(int, int, float64) (float64, float64, error)
+		nSuccess int
+		n        int
+		wantLow  float64
+		wantHigh float64
+	}{
+		{name: "wilson half", interval: wilsonInterval, nSuccess: 50, n: 100, wantLow: 0.403832, wantHigh: 0.596168},
+		{name: "clopper-pearson half", interval: clopperPearsonInterval, nSuccess: 50, n: 100, wantLow: 0.398321, wantHigh: 0.601679},
+		{name: "clopper-pearson none", interval: clopperPearsonInterval, nSuccess: 0, n: 10, wantLow: 0, wantHigh: 1 - math.Pow(0.025, 0.1)},
+		{name: "clopper-pearson all", interval: clopperPearsonInterval, nSuccess: 10, n: 10, wantLow: math.Pow(0.025, 0.1), wantHigh: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			low, high, err := tc.interval(tc.nSuccess, tc.n, 0.95)
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			if math.Abs(low-tc.wantLow) > 1e-5 || math.Abs(high-tc.wantHigh) > 1e-5 {
+				t.Errorf("Expected interval [%f, %f] but got [%f, %f]", tc.wantLow, tc.wantHigh, low, high)
+			}
+		})
+	}
+
+	if _, _, err := wilsonInterval(1, 2, 1); err == nil {
+		t.Errorf("Expected confidence of 1 to fail")
+	}
+}
+
+func TestSimulationResultStdErr(t *testing.T) {
+	// Units left 1, 1, 3, 3
+	result := SimulationResult{
+		NRuns:                         4,
+		NAttackerWon:                  2,
+		TotalAttackerUnitsLeft:        8,
+		TotalSquaredAttackerUnitsLeft: 20,
+	}
+	if got := result.AttackerUnitsLeftVariance(); math.Abs(got-4.0/3) > 1e-12 {
+		t.Errorf("Expected variance %f but got %f", 4.0/3, got)
+	}
+	if got := result.ExpectedAttackerUnitsLeftStdErr(); math.Abs(got-math.Sqrt(1.0/3)) > 1e-12 {
+		t.Errorf("Expected standard error %f but got %f", math.Sqrt(1.0/3), got)
+	}
+}
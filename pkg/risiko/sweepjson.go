@@ -0,0 +1,142 @@
+package risiko
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// Sweep JSON -> Sweeps with what produced them, as a single JSON document or
+// as JSON Lines with the metadata first and then a cell per line
+///////////////////////////////////////////////////////////////////////////////
+
+// What produced a sweep
+type SweepMetadata struct {
+	RulesName        string `json:"rules_name,omitempty"`
+	Rules            Rules  `json:"rules"`
+	AttackerStrategy string `json:"attacker_strategy,omitempty"`
+	DefenderStrategy string `json:"defender_strategy,omitempty"`
+	// Solved exactly rather than simulated
+	Exact bool `json:"exact"`
+	// Simulations only
+	Seed        uint64    `json:"seed,omitempty"`
+	RunsPerCell int       `json:"runs_per_cell,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+	Version     string    `json:"version,omitempty"`
+}
+
+// Outcome of the battles starting from one state. Values that cannot be
+// computed, e.g. units left on win when the attacker never won, are nil.
+type SweepCell struct {
+	AttackerUnits             int      `json:"attacker_units"`
+	DefenderUnits             int      `json:"defender_units"`
+	WinProbability            *float64 `json:"win_probability"`
+	ExpectedAttackerUnitsLeft *float64 `json:"expected_attacker_units_left"`
+	AttackerUnitsLeftOnWin    *float64 `json:"attacker_units_left_on_win"`
+	// Simulations only
+	Runs                            int      `json:"runs,omitempty"`
+	AttackerWon                     int      `json:"attacker_won,omitempty"`
+	WinProbabilityLow               *float64 `json:"win_probability_ci95_low,omitempty"`
+	WinProbabilityHigh              *float64 `json:"win_probability_ci95_high,omitempty"`
+	ExpectedAttackerUnitsLeftStdErr *float64 `json:"expected_attacker_units_left_stderr,omitempty"`
+}
+
+type sweepDocument struct {
+	Metadata *SweepMetadata `json:"metadata"`
+	Cells    []SweepCell    `json:"cells,omitempty"`
+}
+
+// Cells of a simulated or exact sweep, by defender units then attacker units
+func SweepCells[T Outcome](sweep map[int]map[int]T) []SweepCell {
+	cells := []SweepCell{}
+	for nAttackers, column := range sweep {
+		for nDefenders, outcome := range column {
+			cells = append(cells, newSweepCell(BattleState{AttackerUnits: nAttackers, DefenderUnits: nDefenders}, outcome))
+		}
+	}
+	slices.SortFunc(cells, func(a, b SweepCell) int {
+		return cmp.Or(cmp.Compare(a.DefenderUnits, b.DefenderUnits), cmp.Compare(a.AttackerUnits, b.AttackerUnits))
+	})
+	return cells
+}
+
+func newSweepCell(state BattleState, outcome Outcome) SweepCell {
+	cell := SweepCell{
+		AttackerUnits:             state.AttackerUnits,
+		DefenderUnits:             state.DefenderUnits,
+		WinProbability:            jsonFloat(outcome.WinProbability()),
+		ExpectedAttackerUnitsLeft: jsonFloat(outcome.ExpectedAttackerUnitsLeft()),
+		AttackerUnitsLeftOnWin:    jsonFloat(outcome.AttackerUnitsLeftOnWin()),
+	}
+	if result, ok := outcome.(SimulationResult); ok {
+		cell.Runs = result.NRuns
+		cell.AttackerWon = result.NAttackerWon
+		if low, high, err := result.WinRateWilson(0.95); err == nil {
+			cell.WinProbabilityLow, cell.WinProbabilityHigh = jsonFloat(low), jsonFloat(high)
+		}
+		cell.ExpectedAttackerUnitsLeftStdErr = jsonFloat(result.ExpectedAttackerUnitsLeftStdErr())
+	}
+	return cell
+}
+
+// JSON has no NaN nor infinities
+func jsonFloat(v float64) *float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil
+	}
+	return &v
+}
+
+// Writes the sweep as a single JSON document with its metadata and cells
+func WriteSweepJSON[T Outcome](w io.Writer, metadata SweepMetadata, sweep map[int]map[int]T) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sweepDocument{Metadata: &metadata, Cells: SweepCells(sweep)})
+}
+
+// Writes the metadata on the first line and then a cell per line
+func WriteSweepJSONLines[T Outcome](w io.Writer, metadata SweepMetadata, sweep map[int]map[int]T) error {
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(sweepDocument{Metadata: &metadata}); err != nil {
+		return err
+	}
+	for _, cell := range SweepCells(sweep) {
+		if err := encoder.Encode(cell); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reads a sweep written by either WriteSweepJSON or WriteSweepJSONLines
+func ReadSweepJSON(r io.Reader) (SweepMetadata, []SweepCell, error) {
+	decoder := json.NewDecoder(r)
+	var document sweepDocument
+	if err := decoder.Decode(&document); err != nil {
+		return SweepMetadata{}, nil, err
+	}
+	if document.Metadata == nil {
+		return SweepMetadata{}, nil, fmt.Errorf("sweep has no metadata")
+	}
+	cells := document.Cells
+	if cells == nil {
+		cells = []SweepCell{}
+	}
+	for {
+		var cell SweepCell
+		err := decoder.Decode(&cell)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return SweepMetadata{}, nil, fmt.Errorf("cell %d: %w", len(cells)+1, err)
+		}
+		cells = append(cells, cell)
+	}
+	return *document.Metadata, cells, nil
+}
@@ -0,0 +1,93 @@
+package risiko
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSweepJSON(t *testing.T) {
+	nUnitsSweep := 4
+	rules := RiskRules
+	rules.Ties = BothLoseOnTies
+	rules.AttackDice = mustNewFairDice(8)
+	metadata := SweepMetadata{
+		RulesName:        "risk",
+		Rules:            rules,
+		AttackerStrategy: "max",
+		DefenderStrategy: "max",
+		Seed:             42,
+		RunsPerCell:      50,
+		Timestamp:        time.Date(2024, 11, 24, 12, 0, 0, 0, time.UTC),
+		Version:          "v1.2.3",
+	}
+	simulated, err := SimulateSeeded(context.Background(), metadata.Seed, metadata.RunsPerCell, nUnitsSweep, NewMaxAttackersStrategy, NewMaxDefendersStrategy, WithRules(rules))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	writers := map[string]func(io.Writer, SweepMetadata, SimulationSweep) error{
+		"json":  WriteSweepJSON[SimulationResult],
+		"jsonl": WriteSweepJSONLines[SimulationResult],
+	}
+	for name, write := range writers {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := write(&buf, metadata, simulated); err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			gotMetadata, cells, err := ReadSweepJSON(&buf)
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			if !reflect.DeepEqual(gotMetadata, metadata) {
+				t.Errorf("Expected metadata %+v but got %+v", metadata, gotMetadata)
+			}
+			if !reflect.DeepEqual(cells, SweepCells(simulated)) {
+				t.Errorf("Expected cells to survive a round trip")
+			}
+			if want := (nUnitsSweep - 1) * nUnitsSweep; len(cells) != want {
+				t.Errorf("Expected %d cells but got %d", want, len(cells))
+			}
+			for _, cell := range cells {
+				result := simulated[cell.AttackerUnits][cell.DefenderUnits]
+				if cell.Runs != metadata.RunsPerCell || *cell.WinProbability != result.WinProbability() {
+					t.Errorf("Expected cell %+v to match %+v", cell, result)
+				}
+			}
+		})
+	}
+}
+
+func TestSweepCellsExact(t *testing.T) {
+	exact, err := SolveMatchups(RisiKoRules, Matchups{{AttackerUnits: 1, DefenderUnits: 3}, {AttackerUnits: 5, DefenderUnits: 2}})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	cells := SweepCells(exact)
+	if len(cells) != 2 {
+		t.Fatalf("Expected 2 cells but got %d", len(cells))
+	}
+	// The attacker can't attack, so it never wins
+	if cells[1].AttackerUnitsLeftOnWin != nil || *cells[1].WinProbability != 0 {
+		t.Errorf("Expected no units left on win but got %+v", cells[1])
+	}
+	if got := *cells[0].WinProbability; math.Abs(got-exact[5][2].WinProbability()) > 1e-12 {
+		t.Errorf("Expected win probability %f but got %f", exact[5][2].WinProbability(), got)
+	}
+	if cells[0].Runs != 0 || cells[0].WinProbabilityLow != nil {
+		t.Errorf("Expected no sampling fields on exact cells but got %+v", cells[0])
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSweepJSON(&buf, SweepMetadata{Rules: RisiKoRules, Exact: true}, exact); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if _, _, err := ReadSweepJSON(bytes.NewBufferString(`{"cells": []}`)); err == nil {
+		t.Errorf("Expected sweep without metadata to fail")
+	}
+}
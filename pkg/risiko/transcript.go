@@ -0,0 +1,172 @@
+package risiko
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// Transcript -> Every round of a battle, to audit disputed games, replay them
+// or test the full sequence of a battle
+///////////////////////////////////////////////////////////////////////////////
+
+// Dice of the attacker against the dice of the defender with the same rank
+type Comparison struct {
+	AttackerDice int  `json:"attacker_dice"`
+	DefenderDice int  `json:"defender_dice"`
+	AttackerLost bool `json:"attacker_lost"`
+	DefenderLost bool `json:"defender_lost"`
+}
+
+// A single engagement of a battle
+type Round struct {
+	// Starting from 1
+	Number int         `json:"round"`
+	Before BattleState `json:"before"`
+	After  BattleState `json:"after"`
+	// Throws sorted in descending order
+	AttackerThrows []int        `json:"attacker_throws"`
+	DefenderThrows []int        `json:"defender_throws"`
+	Comparisons    []Comparison `json:"comparisons"`
+	AttackerLoss   int          `json:"attacker_loss"`
+	DefenderLoss   int          `json:"defender_loss"`
+}
+
+// How a battle ended, for the attacker
+type BattleEnd string
+
+const (
+	// No defenders left
+	EndWon BattleEnd = "won"
+	// Not enough attackers left to attack
+	EndLost BattleEnd = "lost"
+	// The attacker stopped attacking
+	EndRetreated BattleEnd = "retreated"
+)
+
+// Last line of a transcript, after every round
+type Ending struct {
+	End    BattleEnd   `json:"end"`
+	Final  BattleState `json:"final"`
+	Rounds int         `json:"rounds"`
+}
+
+// Receives every round of a battle as it's played, see BattleRecorded
+type Recorder interface {
+	RecordRound(Round)
+}
+
+// Recorder also told how the battle ended, after its last round
+type EndRecorder interface {
+	Recorder
+	RecordEnding(Ending)
+}
+
+// Builds the round from throws already sorted in descending order
+func newRound(rules Rules, number int, before BattleState, after BattleState, attackerThrows []int, defenderThrows []int) Round {
+	round := Round{
+		Number:         number,
+		Before:         before,
+		After:          after,
+		AttackerThrows: attackerThrows,
+		DefenderThrows: defenderThrows,
+		Comparisons:    []Comparison{},
+		AttackerLoss:   before.AttackerUnits - after.AttackerUnits,
+		DefenderLoss:   before.DefenderUnits - after.DefenderUnits,
+	}
+	for i := range min(len(attackerThrows), len(defenderThrows)) {
+		attLoss, defLoss := compareDices(rules, attackerThrows[i], defenderThrows[i])
+		round.Comparisons = append(round.Comparisons, Comparison{
+			AttackerDice: attackerThrows[i],
+			DefenderDice: defenderThrows[i],
+			AttackerLost: attLoss > 0,
+			DefenderLost: defLoss > 0,
+		})
+	}
+	return round
+}
+
+// Recorder keeping every round in memory. It is not safe for concurrent use,
+// use one per battle.
+type Transcript struct {
+	Rounds []Round
+	// Nil until the battle is over
+	Ending *Ending
+}
+
+func (t *Transcript) RecordRound(round Round) {
+	t.Rounds = append(t.Rounds, round)
+}
+
+func (t *Transcript) RecordEnding(ending Ending) {
+	t.Ending = &ending
+}
+
+// State the battle ended in, or after the last round when the ending is
+// missing. False when there is neither.
+func (t *Transcript) Final() (BattleState, bool) {
+	if t.Ending != nil {
+		return t.Ending.Final, true
+	}
+	if len(t.Rounds) == 0 {
+		return BattleState{}, false
+	}
+	return t.Rounds[len(t.Rounds)-1].After, true
+}
+
+// Writes one JSON object per round and per line, then the ending when the
+// battle is over
+func (t *Transcript) WriteJSONLines(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	for _, round := range t.Rounds {
+		if err := encoder.Encode(round); err != nil {
+			return err
+		}
+	}
+	if t.Ending != nil {
+		return encoder.Encode(t.Ending)
+	}
+	return nil
+}
+
+// Reads a transcript written by WriteJSONLines, skipping blank lines
+func ReadTranscript(r io.Reader) (*Transcript, error) {
+	t := &Transcript{}
+	scanner := bufio.NewScanner(r)
+	nLine := 0
+	for scanner.Scan() {
+		nLine++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		if t.Ending != nil {
+			return nil, fmt.Errorf("line %d: round after the end of the battle", nLine)
+		}
+		// Only the ending has an end
+		var line struct {
+			End BattleEnd `json:"end"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return nil, fmt.Errorf("line %d: %w", nLine, err)
+		}
+		if line.End != "" {
+			var ending Ending
+			if err := json.Unmarshal(scanner.Bytes(), &ending); err != nil {
+				return nil, fmt.Errorf("line %d: %w", nLine, err)
+			}
+			t.Ending = &ending
+			continue
+		}
+		var round Round
+		if err := json.Unmarshal(scanner.Bytes(), &round); err != nil {
+			return nil, fmt.Errorf("line %d: %w", nLine, err)
+		}
+		t.Rounds = append(t.Rounds, round)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
@@ -0,0 +1,119 @@
+package risiko
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestBattleRecorded(t *testing.T) {
+	transcript := &Transcript{}
+	final, err := BattleRecorded(RisiKoRules, BattleState{AttackerUnits: 5, DefenderUnits: 4},
+		NewMaxAttackersStrategy(createTestSingleSidedDicesGen(6)),
+		NewMaxDefendersStrategy(createTestSingleSidedDicesGen(3)),
+		transcript)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	want := []Round{
+		{
+			Number:         1,
+			Before:         BattleState{AttackerUnits: 5, DefenderUnits: 4},
+			After:          BattleState{AttackerUnits: 5, DefenderUnits: 1},
+			AttackerThrows: []int{6, 6, 6},
+			DefenderThrows: []int{3, 3, 3},
+			Comparisons: []Comparison{
+				{AttackerDice: 6, DefenderDice: 3, DefenderLost: true},
+				{AttackerDice: 6, DefenderDice: 3, DefenderLost: true},
+				{AttackerDice: 6, DefenderDice: 3, DefenderLost: true},
+			},
+			DefenderLoss: 3,
+		},
+		{
+			Number:         2,
+			Before:         BattleState{AttackerUnits: 5, DefenderUnits: 1},
+			After:          BattleState{AttackerUnits: 5, DefenderUnits: 0},
+			AttackerThrows: []int{6, 6, 6},
+			DefenderThrows: []int{3},
+			Comparisons: []Comparison{
+				{AttackerDice: 6, DefenderDice: 3, DefenderLost: true},
+			},
+			DefenderLoss: 1,
+		},
+	}
+	if !reflect.DeepEqual(transcript.Rounds, want) {
+		t.Errorf("Expected rounds %+v but got %+v", want, transcript.Rounds)
+	}
+	if got, ok := transcript.Final(); !ok || got != final {
+		t.Errorf("Expected transcript to end at %v but got %v", final, got)
+	}
+}
+
+func TestBattleRecordedTies(t *testing.T) {
+	rules := RiskRules
+	rules.Ties = BothLoseOnTies
+	transcript := &Transcript{}
+	_, err := BattleRecorded(rules, BattleState{AttackerUnits: 2, DefenderUnits: 1},
+		NewMaxAttackersStrategy(createTestSingleSidedDicesGen(4)),
+		NewMaxDefendersStrategy(createTestSingleSidedDicesGen(4)),
+		transcript)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(transcript.Rounds) != 1 {
+		t.Fatalf("Expected a single round but got %d", len(transcript.Rounds))
+	}
+	round := transcript.Rounds[0]
+	want := []Comparison{{AttackerDice: 4, DefenderDice: 4, AttackerLost: true, DefenderLost: true}}
+	if !reflect.DeepEqual(round.Comparisons, want) {
+		t.Errorf("Expected comparisons %+v but got %+v", want, round.Comparisons)
+	}
+	if round.AttackerLoss != 1 || round.DefenderLoss != 1 {
+		t.Errorf("Expected both sides to lose a unit but got %d and %d", round.AttackerLoss, round.DefenderLoss)
+	}
+}
+
+func TestTranscriptJSONLines(t *testing.T) {
+	transcript := &Transcript{}
+	final, err := BattleRecorded(RisiKoRules, BattleState{AttackerUnits: 10, DefenderUnits: 10},
+		NewMaxAttackersStrategy(NewSeededDicesGen(42, 0)),
+		NewMaxDefendersStrategy(NewSeededDicesGen(42, 1)),
+		transcript)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := transcript.WriteJSONLines(&buf); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	// A line per round and the ending
+	if got := bytes.Count(buf.Bytes(), []byte("\n")); got != len(transcript.Rounds)+1 {
+		t.Errorf("Expected %d lines but got %d", len(transcript.Rounds)+1, got)
+	}
+	wantEnding := Ending{End: EndLost, Final: final, Rounds: len(transcript.Rounds)}
+	if final.AttackerWon() {
+		wantEnding.End = EndWon
+	}
+	if transcript.Ending == nil || *transcript.Ending != wantEnding {
+		t.Errorf("Expected ending %+v but got %+v", wantEnding, transcript.Ending)
+	}
+	read, err := ReadTranscript(&buf)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if !reflect.DeepEqual(read, transcript) {
+		t.Errorf("Expected transcript to survive a round trip")
+	}
+	if got, _ := read.Final(); got != final {
+		t.Errorf("Expected transcript to end at %v but got %v", final, got)
+	}
+
+	if _, err := ReadTranscript(bytes.NewBufferString("{\"round\": 1}\nnot json\n")); err == nil {
+		t.Errorf("Expected malformed line to fail")
+	}
+	if _, err := ReadTranscript(bytes.NewBufferString("{\"end\": \"won\"}\n{\"round\": 1}\n")); err == nil {
+		t.Errorf("Expected a round after the ending to fail")
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/Ax6/risiko/pkg/risiko"
+)
+
+// Walks through a battle rolled with real dices, asking each side for its roll
+// on stdin and printing the state after every round
+func runPlay(ctx context.Context, args []string) error {
+	flags := newFlagSet("play", "Walks through a battle rolled with real dices, asking each side for its roll\nand printing the units left after every round.")
+	nAttackers := flags.Int("attackers", 3, "attacking units")
+	nDefenders := flags.Int("defenders", 1, "defending units")
+	rulesName := flags.String("rules", "risiko", fmt.Sprintf("rules to play, any of %v", risiko.RulesPresetNames()))
+	attackerSpec := flags.String("attacker", "max", attackerStrategyHelp)
+	defenderSpec := flags.String("defender", "max", defenderStrategyHelp)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	rules, err := risiko.RulesPreset(*rulesName)
+	if err != nil {
+		return &usageError{err: err}
+	}
+	attacker, err := parseStrategy(risiko.AttackerSide, *attackerSpec)
+	if err != nil {
+		return err
+	}
+	defender, err := parseStrategy(risiko.DefenderSide, *defenderSpec)
+	if err != nil {
+		return err
+	}
+	state := risiko.BattleState{AttackerUnits: *nAttackers, DefenderUnits: *nDefenders}
+	if state.AttackerUnits < 0 || state.DefenderUnits < 0 {
+		return usageErrorf("cannot play with negative units %v", state)
+	}
+
+	// Both sides read from the same input so they share its buffer
+	rolls := risiko.NewReaderDicesGen(os.Stdin)
+	attackerGen := promptDicesGen(os.Stdout, rolls, risiko.AttackerSide, rules.Dice(risiko.AttackerSide).Faces())
+	defenderGen := promptDicesGen(os.Stdout, rolls, risiko.DefenderSide, rules.Dice(risiko.DefenderSide).Faces())
+
+	fmt.Printf("Battle starts with %d attackers against %d defenders, type each roll as e.g. \"6 4 1\"\n", state.AttackerUnits, state.DefenderUnits)
+	final, err := risiko.BattleRecorded(rules, state, attacker(attackerGen), defender(defenderGen), &roundPrinter{w: os.Stdout})
+	if err != nil {
+		return err
+	}
+	printFinalState(final)
+	return nil
+}
+
+// Asks the side for its roll before reading it from gen, asking again until
+// the roll is valid for a dice with the given faces
+func promptDicesGen(w io.Writer, gen risiko.DicesGenerator, side risiko.Side, faces int) risiko.DicesGenerator {
+	return func(count int) (risiko.Dices, error) {
+		for {
+			fmt.Fprintf(w, "%v, roll %d dices: ", side, count)
+			dices, err := gen(count)
+			if err == nil && slices.Max(append(dices.Roll(), 1)) > faces {
+				err = fmt.Errorf("%w: dices have faces from 1 to %d", risiko.ErrInvalidRoll, faces)
+			}
+			if !errors.Is(err, risiko.ErrInvalidRoll) {
+				return dices, err
+			}
+			fmt.Fprintf(w, "%v, try again\n", err)
+		}
+	}
+}
+
+// Prints every round of a battle
+type roundPrinter struct {
+	w io.Writer
+}
+
+func (p *roundPrinter) RecordRound(round risiko.Round) {
+	fmt.Fprintf(p.w, "Round %d: %v against %v, attacker loses %d and defender loses %d\n",
+		round.Number, round.AttackerThrows, round.DefenderThrows, round.AttackerLoss, round.DefenderLoss)
+	fmt.Fprintf(p.w, "%d attackers against %d defenders\n", round.After.AttackerUnits, round.After.DefenderUnits)
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Ax6/risiko/pkg/heatmap"
+	"github.com/Ax6/risiko/pkg/risiko"
+)
+
+// Draws the heatmaps of a sweep saved as JSON
+func runPlot(ctx context.Context, args []string) error {
+	flags := newFlagSet("plot", "Draws the heatmaps of a sweep written with -format json or jsonl, the same\nas the README images.")
+	input := flags.String("in", "sweep.json", "sweep to plot")
+	plotFormats := flags.String("format", "png", "comma separated image formats, png or svg")
+	outDir := flags.String("out", ".", "directory the images are written to")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	formats, err := parsePlotFormats(*plotFormats)
+	if err != nil {
+		return err
+	}
+	if len(formats) == 0 {
+		return usageErrorf("-format needs at least one image format")
+	}
+
+	file, err := os.Open(*input)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	metadata, cells, err := risiko.ReadSweepJSON(file)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", *input, err)
+	}
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		return err
+	}
+	return savePlots(cells, metadata.RulesName, formats, *outDir, "")
+}
+
+// A table of the sweep drawn as a heatmap
+type sweepPlot struct {
+	name     string
+	title    string
+	value    func(risiko.SweepCell) *float64
+	percent  bool
+	decimals int
+}
+
+// Same tables as the README images
+var sweepPlots = []sweepPlot{
+	{
+		name:    "winning_probability",
+		title:   "% of winning when attacking",
+		value:   func(c risiko.SweepCell) *float64 { return c.WinProbability },
+		percent: true,
+	},
+	{
+		name:  "expected_units_left",
+		title: "% of expected units left when attacking",
+		value: func(c risiko.SweepCell) *float64 {
+			if c.ExpectedAttackerUnitsLeft == nil || c.AttackerUnits == 0 {
+				return nil
+			}
+			v := *c.ExpectedAttackerUnitsLeft / float64(c.AttackerUnits)
+			return &v
+		},
+		percent: true,
+	},
+	{
+		name:     "units_left",
+		title:    "Average units left when attacker wins",
+		value:    func(c risiko.SweepCell) *float64 { return c.AttackerUnitsLeftOnWin },
+		decimals: 1,
+	},
+}
+
+// Parses comma separated plot formats, png or svg
+func parsePlotFormats(formats string) ([]string, error) {
+	if formats == "" {
+		return nil, nil
+	}
+	parsed := strings.Split(formats, ",")
+	for _, format := range parsed {
+		if format != "png" && format != "svg" {
+			return nil, usageErrorf("unknown plot format %q, want png or svg", format)
+		}
+	}
+	return parsed, nil
+}
+
+// Cells of a sweep laid out by defender units then attacker units, nil where
+// the sweep skipped the battle
+type sweepGrid struct {
+	columns []int
+	rows    []int
+	cells   [][]*risiko.SweepCell
+}
+
+func newSweepGrid(cells []risiko.SweepCell, others ...[]risiko.SweepCell) sweepGrid {
+	var g sweepGrid
+	for _, sweep := range append([][]risiko.SweepCell{cells}, others...) {
+		for _, cell := range sweep {
+			g.columns = append(g.columns, cell.AttackerUnits)
+			g.rows = append(g.rows, cell.DefenderUnits)
+		}
+	}
+	slices.Sort(g.columns)
+	g.columns = slices.Compact(g.columns)
+	slices.Sort(g.rows)
+	g.rows = slices.Compact(g.rows)
+	return g.with(cells)
+}
+
+// Grid with the same rows and columns holding the given cells
+func (g sweepGrid) with(cells []risiko.SweepCell) sweepGrid {
+	g.cells = make([][]*risiko.SweepCell, len(g.rows))
+	for i := range g.cells {
+		g.cells[i] = make([]*risiko.SweepCell, len(g.columns))
+	}
+	for k, cell := range cells {
+		i, okRow := slices.BinarySearch(g.rows, cell.DefenderUnits)
+		j, okColumn := slices.BinarySearch(g.columns, cell.AttackerUnits)
+		if okRow && okColumn {
+			g.cells[i][j] = &cells[k]
+		}
+	}
+	return g
+}
+
+// Value of the plot in the cell, NaN when missing
+func (p sweepPlot) at(cell *risiko.SweepCell) float64 {
+	if cell == nil {
+		return math.NaN()
+	}
+	if v := p.value(*cell); v != nil {
+		return *v
+	}
+	return math.NaN()
+}
+
+// Heatmap of the plot with a row per defender units and a column per attacker
+// units of the cells
+func (p sweepPlot) heatmap(cells []risiko.SweepCell, rulesName string) *heatmap.Heatmap {
+	return p.gridHeatmap(newSweepGrid(cells), rulesName)
+}
+
+func (p sweepPlot) gridHeatmap(g sweepGrid, rulesName string) *heatmap.Heatmap {
+	h := &heatmap.Heatmap{
+		Title:   p.title,
+		XLabel:  "Attackers",
+		YLabel:  "Defenders",
+		Columns: g.columns,
+		Rows:    g.rows,
+		Values:  make([][]float64, len(g.rows)),
+	}
+	if rulesName != "" {
+		h.Title += " (" + rulesName + ")"
+	}
+	for i, row := range g.cells {
+		h.Values[i] = make([]float64, len(row))
+		for j, cell := range row {
+			h.Values[i][j] = p.at(cell)
+		}
+	}
+
+	if p.percent {
+		// Fixed scale so that white is always an even battle
+		h.Min, h.Max = 0, 1
+		h.Annotate = func(v float64) string { return fmt.Sprintf("%.0f", v*100) }
+	} else {
+		h.Annotate = func(v float64) string { return fmt.Sprintf("%.*f", p.decimals, v) }
+	}
+	return h
+}
+
+// Writes every plot of the cells in every format, nothing without formats
+func savePlots(cells []risiko.SweepCell, rulesName string, formats []string, dir string, prefix string) error {
+	if len(formats) == 0 {
+		return nil
+	}
+	for _, p := range sweepPlots {
+		h := p.heatmap(cells, rulesName)
+		for _, format := range formats {
+			filename := filepath.Join(dir, prefix+p.name+"."+format)
+			if err := savePlot(h, format, filename); err != nil {
+				return fmt.Errorf("saving %s: %w", filename, err)
+			}
+		}
+	}
+	log.Println("Plots created successfully.")
+	return nil
+}
+
+func savePlot(h *heatmap.Heatmap, format string, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if format == "svg" {
+		err = h.WriteSVG(file)
+	} else {
+		err = h.WritePNG(file)
+	}
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
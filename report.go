@@ -0,0 +1,368 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Ax6/risiko/pkg/risiko"
+)
+
+//go:embed report.html.tmpl
+var reportTemplateText string
+
+var reportTemplate = template.Must(template.New("report").Parse(reportTemplateText))
+
+// Writes a single HTML page with the heatmaps of a sweep, or of two sweeps and
+// their differences
+func runReport(ctx context.Context, args []string) error {
+	flags := newFlagSet("report", "Writes a self-contained HTML report of a sweep written with -format json or\njsonl: the heatmaps with the exact numbers on hover and the run metadata.\nWith -compare it also shows how a second sweep differs from the first one.")
+	input := flags.String("in", "sweep.json", "sweep to report")
+	compare := flags.String("compare", "", "second sweep to compare with the first one, e.g. with another strategy")
+	output := flags.String("out", "report.html", "HTML file to write")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	sweeps := []reportSweep{}
+	for _, filename := range []string{*input, *compare} {
+		if filename == "" {
+			continue
+		}
+		sweep, err := readReportSweep(filename)
+		if err != nil {
+			return err
+		}
+		sweeps = append(sweeps, sweep)
+	}
+	return saveReport(*output, sweeps...)
+}
+
+// Sweep shown in a report
+type reportSweep struct {
+	label    string
+	metadata risiko.SweepMetadata
+	cells    []risiko.SweepCell
+}
+
+func readReportSweep(filename string) (reportSweep, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return reportSweep{}, err
+	}
+	defer file.Close()
+	metadata, cells, err := risiko.ReadSweepJSON(file)
+	if err != nil {
+		return reportSweep{}, fmt.Errorf("reading %s: %w", filename, err)
+	}
+	return reportSweep{label: filepath.Base(filename), metadata: metadata, cells: cells}, nil
+}
+
+// Writes the report of one sweep, or of several compared with the first one
+func saveReport(filename string, sweeps ...reportSweep) error {
+	var buf bytes.Buffer
+	if err := writeReport(&buf, sweeps); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filename, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	log.Printf("%s created successfully.", filename)
+	return nil
+}
+
+type reportPage struct {
+	Title       string
+	Sweeps      []reportSection
+	Comparisons []reportSection
+}
+
+type reportSection struct {
+	Title    string
+	Metadata [][2]string
+	Summary  []string
+	Plots    []template.HTML
+}
+
+func writeReport(w io.Writer, sweeps []reportSweep) error {
+	if len(sweeps) == 0 {
+		return fmt.Errorf("a report needs at least one sweep")
+	}
+	labels := []string{}
+	for _, sweep := range sweeps {
+		labels = append(labels, sweepLabel(sweep.metadata))
+	}
+	if len(slices.Compact(slices.Sorted(slices.Values(labels)))) == len(labels) {
+		// The file names only matter when the sweeps look the same
+		for i := range sweeps {
+			sweeps[i].label = labels[i]
+		}
+	}
+
+	page := reportPage{Title: "RisiKo! sweep report"}
+	for _, sweep := range sweeps {
+		section := reportSection{Title: sweep.label, Metadata: metadataRows(sweep.metadata)}
+		g := newSweepGrid(sweep.cells)
+		for _, p := range sweepPlots {
+			h := p.gridHeatmap(g, sweep.metadata.RulesName)
+			h.Tooltip = func(row int, column int) string {
+				return cellTooltip(g.cells[row][column])
+			}
+			svg, err := inlineSVG(h.WriteSVG)
+			if err != nil {
+				return err
+			}
+			section.Plots = append(section.Plots, svg)
+		}
+		page.Sweeps = append(page.Sweeps, section)
+	}
+
+	for _, sweep := range sweeps[1:] {
+		comparison, err := compareSweeps(sweeps[0], sweep)
+		if err != nil {
+			return err
+		}
+		page.Comparisons = append(page.Comparisons, comparison)
+	}
+	return reportTemplate.Execute(w, page)
+}
+
+// Short description of what produced a sweep
+func sweepLabel(m risiko.SweepMetadata) string {
+	label := fmt.Sprintf("%s, %s attacker vs %s defender", cmp.Or(m.RulesName, "custom rules"), cmp.Or(m.AttackerStrategy, "unknown"), cmp.Or(m.DefenderStrategy, "unknown"))
+	if m.Exact {
+		return label + ", exact"
+	}
+	return label + ", simulated"
+}
+
+func metadataRows(m risiko.SweepMetadata) [][2]string {
+	rules := m.Rules
+	rows := [][2]string{
+		{"Rules", cmp.Or(m.RulesName, "custom")},
+		{"Dices", fmt.Sprintf("attacker up to %d %s, defender up to %d %s", rules.MaxAttackDices, describeDice(rules.AttackDice), rules.MaxDefenceDices, describeDice(rules.DefenceDice))},
+		{"Attack", fmt.Sprintf("with at least %d units, %d staying behind", rules.MinAttackUnits, rules.UnitsStayBehind)},
+		{"Ties won by", rules.Ties.String()},
+		{"Attacker strategy", cmp.Or(m.AttackerStrategy, "unknown")},
+		{"Defender strategy", cmp.Or(m.DefenderStrategy, "unknown")},
+	}
+	if m.Exact {
+		rows = append(rows, [2]string{"Method", "solved exactly"})
+	} else {
+		rows = append(rows, [2]string{"Method", fmt.Sprintf("simulated, %d runs per battle, seed %d", m.RunsPerCell, m.Seed)})
+	}
+	if !m.Timestamp.IsZero() {
+		rows = append(rows, [2]string{"Created", m.Timestamp.Format("2006-01-02 15:04:05 MST")})
+	}
+	if m.Version != "" {
+		rows = append(rows, [2]string{"Version", m.Version})
+	}
+	return rows
+}
+
+func describeDice(dice *risiko.Dice) string {
+	if dice == nil {
+		return "six sided dices"
+	}
+	probabilities := make([]string, dice.Faces())
+	fair := true
+	for face := 1; face <= dice.Faces(); face++ {
+		p := dice.Probability(face)
+		probabilities[face-1] = fmt.Sprintf("%.3f", p)
+		fair = fair && math.Abs(p-1/float64(dice.Faces())) < 1e-9
+	}
+	if fair {
+		return fmt.Sprintf("%d sided dices", dice.Faces())
+	}
+	return fmt.Sprintf("%d sided dices loaded as %s", dice.Faces(), strings.Join(probabilities, " "))
+}
+
+// Exact numbers of a cell shown on hover
+func cellTooltip(cell *risiko.SweepCell) string {
+	if cell == nil {
+		return ""
+	}
+	lines := []string{fmt.Sprintf("%d attackers vs %d defenders", cell.AttackerUnits, cell.DefenderUnits)}
+	if cell.WinProbability != nil {
+		line := fmt.Sprintf("Win: %.2f%%", *cell.WinProbability*100)
+		if cell.WinProbabilityLow != nil && cell.WinProbabilityHigh != nil {
+			line += fmt.Sprintf(" (95%% CI %.2f%% to %.2f%%)", *cell.WinProbabilityLow*100, *cell.WinProbabilityHigh*100)
+		}
+		lines = append(lines, line)
+	}
+	if cell.ExpectedAttackerUnitsLeft != nil {
+		line := fmt.Sprintf("Expected units left: %.3f", *cell.ExpectedAttackerUnitsLeft)
+		if cell.ExpectedAttackerUnitsLeftStdErr != nil {
+			line += fmt.Sprintf(" ± %.3f", *cell.ExpectedAttackerUnitsLeftStdErr)
+		}
+		lines = append(lines, line)
+	}
+	if cell.AttackerUnitsLeftOnWin != nil {
+		lines = append(lines, fmt.Sprintf("Units left on win: %.3f", *cell.AttackerUnitsLeftOnWin))
+	}
+	if cell.Runs > 0 {
+		lines = append(lines, fmt.Sprintf("Attacker won %d of %d runs", cell.AttackerWon, cell.Runs))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Heatmaps of the second sweep minus the first one, over the battles of both
+func compareSweeps(a reportSweep, b reportSweep) (reportSection, error) {
+	section := reportSection{Title: fmt.Sprintf("%s compared with %s", b.label, a.label)}
+	// The first rows of the metadata describe the rules
+	if !slices.Equal(metadataRows(a.metadata)[:4], metadataRows(b.metadata)[:4]) {
+		section.Summary = append(section.Summary, "The sweeps play different rules.")
+	}
+
+	g := newSweepGrid(a.cells, b.cells)
+	gridA, gridB := g.with(a.cells), g.with(b.cells)
+	for _, p := range sweepPlots {
+		diff := p.gridHeatmap(gridA, "")
+		diff.Title = "Difference in " + strings.ToLower(p.title[:1]) + p.title[1:]
+		limit := 0.0
+		for i := range diff.Values {
+			for j := range diff.Values[i] {
+				d := p.at(gridB.cells[i][j]) - p.at(gridA.cells[i][j])
+				diff.Values[i][j] = d
+				if !math.IsNaN(d) {
+					limit = max(limit, math.Abs(d))
+				}
+			}
+		}
+		if limit == 0 {
+			limit = 1
+		}
+		// Symmetric scale so that white is always no difference
+		diff.Min, diff.Max = -limit, limit
+		if p.percent {
+			diff.Annotate = func(v float64) string { return fmt.Sprintf("%+.0f", v*100) }
+		} else {
+			diff.Annotate = func(v float64) string { return fmt.Sprintf("%+.*f", p.decimals, v) }
+		}
+		diff.Tooltip = func(row int, column int) string {
+			return comparisonTooltip(p, a.label, gridA.cells[row][column], b.label, gridB.cells[row][column])
+		}
+		svg, err := inlineSVG(diff.WriteSVG)
+		if err != nil {
+			return reportSection{}, err
+		}
+		section.Plots = append(section.Plots, svg)
+	}
+	section.Summary = append(section.Summary, winSummary(b.label, gridA, gridB)...)
+	return section, nil
+}
+
+// Values of both sweeps in a cell shown on hover
+func comparisonTooltip(p sweepPlot, labelA string, a *risiko.SweepCell, labelB string, b *risiko.SweepCell) string {
+	cell := cmpOrCell(a, b)
+	if cell == nil {
+		return ""
+	}
+	format := func(v float64) string {
+		if math.IsNaN(v) {
+			return "none"
+		}
+		if p.percent {
+			return fmt.Sprintf("%.2f%%", v*100)
+		}
+		return fmt.Sprintf("%.3f", v)
+	}
+	lines := []string{
+		fmt.Sprintf("%d attackers vs %d defenders", cell.AttackerUnits, cell.DefenderUnits),
+		fmt.Sprintf("%s: %s", labelA, format(p.at(a))),
+		fmt.Sprintf("%s: %s", labelB, format(p.at(b))),
+	}
+	if p.name == "winning_probability" && significantlyDifferent(a, b) {
+		lines = append(lines, "The 95% confidence intervals don't overlap")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func cmpOrCell(a *risiko.SweepCell, b *risiko.SweepCell) *risiko.SweepCell {
+	if a != nil {
+		return a
+	}
+	return b
+}
+
+// Whether both cells have a confidence interval of the win probability and
+// the intervals don't overlap. Exact sweeps have none.
+func significantlyDifferent(a *risiko.SweepCell, b *risiko.SweepCell) bool {
+	if !hasInterval(a) || !hasInterval(b) {
+		return false
+	}
+	return *a.WinProbabilityHigh < *b.WinProbabilityLow || *b.WinProbabilityHigh < *a.WinProbabilityLow
+}
+
+func hasInterval(cell *risiko.SweepCell) bool {
+	return cell != nil && cell.WinProbabilityLow != nil && cell.WinProbabilityHigh != nil
+}
+
+// How the win probability of the second sweep compares over the battles of
+// both sweeps
+func winSummary(labelB string, a sweepGrid, b sweepGrid) []string {
+	win := sweepPlots[0]
+	nCompared, nBetter, nWorse, nIntervals, nSignificant := 0, 0, 0, 0, 0
+	total := 0.0
+	largest, largestAt := 0.0, ""
+	for i := range a.cells {
+		for j := range a.cells[i] {
+			d := win.at(b.cells[i][j]) - win.at(a.cells[i][j])
+			if math.IsNaN(d) {
+				continue
+			}
+			nCompared++
+			total += d
+			switch {
+			case d > 0:
+				nBetter++
+			case d < 0:
+				nWorse++
+			}
+			if hasInterval(a.cells[i][j]) && hasInterval(b.cells[i][j]) {
+				nIntervals++
+			}
+			if significantlyDifferent(a.cells[i][j], b.cells[i][j]) {
+				nSignificant++
+			}
+			if math.Abs(d) > math.Abs(largest) {
+				largest, largestAt = d, fmt.Sprintf("%d attackers vs %d defenders", a.columns[j], a.rows[i])
+			}
+		}
+	}
+	if nCompared == 0 {
+		return []string{"The sweeps have no battle in common."}
+	}
+	summary := []string{
+		fmt.Sprintf("%d battles in both sweeps. The attacker of %s wins more often in %d of them and less often in %d.", nCompared, labelB, nBetter, nWorse),
+		fmt.Sprintf("The win probability changes by %+.2f points on average.", total/float64(nCompared)*100),
+	}
+	if largestAt != "" {
+		summary = append(summary, fmt.Sprintf("The largest change is %+.2f points with %s.", largest*100, largestAt))
+	}
+	// Only simulated battles have confidence intervals
+	if nIntervals > 0 {
+		summary = append(summary, fmt.Sprintf("%d of the %d battles simulated in both sweeps have non-overlapping 95%% confidence intervals.", nSignificant, nIntervals))
+	}
+	return summary
+}
+
+// SVG written by write to embed in the page
+func inlineSVG(write func(w io.Writer) error) (template.HTML, error) {
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		return "", err
+	}
+	// The SVG escapes all of its text
+	return template.HTML(buf.String()), nil
+}
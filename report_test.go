@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Ax6/risiko/pkg/risiko"
+)
+
+func newTestCell(win float64, interval ...float64) risiko.SweepCell {
+	cell := risiko.SweepCell{AttackerUnits: 3, DefenderUnits: 1, WinProbability: &win}
+	if len(interval) == 2 {
+		cell.WinProbabilityLow, cell.WinProbabilityHigh = &interval[0], &interval[1]
+	}
+	return cell
+}
+
+func TestSignificantlyDifferent(t *testing.T) {
+	testCases := []struct {
+		name string
+		a    risiko.SweepCell
+		b    risiko.SweepCell
+		want bool
+	}{
+		{name: "apart", a: newTestCell(0.2, 0.18, 0.22), b: newTestCell(0.3, 0.28, 0.32), want: true},
+		{name: "overlapping", a: newTestCell(0.2, 0.18, 0.22), b: newTestCell(0.21, 0.19, 0.23)},
+		{name: "both exact", a: newTestCell(0.2), b: newTestCell(0.3)},
+		{name: "one exact", a: newTestCell(0.2), b: newTestCell(0.3, 0.28, 0.32)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := significantlyDifferent(&tc.a, &tc.b); got != tc.want {
+				t.Errorf("Expected %t but got %t", tc.want, got)
+			}
+		})
+	}
+	if significantlyDifferent(nil, &testCases[0].b) {
+		t.Errorf("Expected a missing battle not to differ")
+	}
+}
+
+func TestWinSummary(t *testing.T) {
+	summarize := func(a risiko.SweepCell, b risiko.SweepCell) string {
+		g := newSweepGrid([]risiko.SweepCell{a}, []risiko.SweepCell{b})
+		return strings.Join(winSummary("b", g.with([]risiko.SweepCell{a}), g.with([]risiko.SweepCell{b})), " ")
+	}
+	if got := summarize(newTestCell(0.2), newTestCell(0.3)); strings.Contains(got, "confidence") {
+		t.Errorf("Expected exact sweeps not to mention confidence intervals but got %q", got)
+	}
+	if got := summarize(newTestCell(0.2, 0.18, 0.22), newTestCell(0.3, 0.28, 0.32)); !strings.Contains(got, "1 of the 1 battles simulated") {
+		t.Errorf("Expected simulated sweeps to count non-overlapping intervals but got %q", got)
+	}
+}
+
+func TestWriteReportSeveralSweeps(t *testing.T) {
+	sweeps := []reportSweep{}
+	for i, name := range []string{"risiko", "risk", "custom"} {
+		win := 0.1 * float64(i+1)
+		sweeps = append(sweeps, reportSweep{
+			label:    name + ".json",
+			metadata: risiko.SweepMetadata{RulesName: name, Rules: risiko.RisiKoRules, Exact: true},
+			cells:    []risiko.SweepCell{newTestCell(win)},
+		})
+	}
+	var buf bytes.Buffer
+	if err := writeReport(&buf, sweeps); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if got := strings.Count(buf.String(), " compared with risiko,"); got != 2 {
+		t.Errorf("Expected 2 comparisons with the first sweep but got %d", got)
+	}
+	if err := writeReport(&buf, nil); err == nil {
+		t.Errorf("Expected a report without sweeps to fail")
+	}
+}
+
+func TestSweepReportSeveralRules(t *testing.T) {
+	dir := t.TempDir()
+	if got := run([]string{"sweep", "-exact", "-units", "3", "-rules", "risiko,risk", "-report", "-format", "json", "-out", dir}); got != exitOK {
+		t.Fatalf("Expected exit code %d but got %d", exitOK, got)
+	}
+	report, err := os.ReadFile(filepath.Join(dir, "report.html"))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if !strings.Contains(string(report), "risk, max attacker vs max defender, exact compared with risiko") {
+		t.Errorf("Expected the report to compare the risk rules with the risiko ones")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "risiko_report.html")); err == nil {
+		t.Errorf("Expected a single report for all the rules")
+	}
+}
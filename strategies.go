@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Ax6/risiko/pkg/risiko"
+)
+
+const attackerStrategyHelp = "attacker strategy: max, cap:N to throw at most N dices, or retreat:units=N,ratio=X,odds=P to retreat below any of the thresholds"
+const defenderStrategyHelp = "defender strategy: max, or cap:N to throw at most N dices"
+
+// Parses a strategy flag of the side, see attackerStrategyHelp and
+// defenderStrategyHelp
+func parseStrategy(side risiko.Side, spec string) (risiko.StrategyFactory, error) {
+	name, params, _ := strings.Cut(spec, ":")
+	switch {
+	case name == "max" && params == "":
+		if side == risiko.AttackerSide {
+			return risiko.NewMaxAttackersStrategy, nil
+		}
+		return risiko.NewMaxDefendersStrategy, nil
+	case name == "cap":
+		n, err := strconv.Atoi(params)
+		if err != nil || n < 1 {
+			return nil, usageErrorf("%v strategy %q needs a positive number of dices", side, spec)
+		}
+		policy := risiko.NewCappedDicesPolicy(n)
+		if side == risiko.AttackerSide {
+			return func(gen risiko.DicesGenerator) risiko.BattleStrategy {
+				return risiko.NewPolicyAttackersStrategy(gen, policy)
+			}, nil
+		}
+		return func(gen risiko.DicesGenerator) risiko.BattleStrategy {
+			return risiko.NewPolicyDefendersStrategy(gen, policy)
+		}, nil
+	case name == "retreat" && side == risiko.AttackerSide:
+		thresholds, err := parseRetreatThresholds(params)
+		if err != nil {
+			return nil, usageErrorf("attacker strategy %q: %v", spec, err)
+		}
+		return func(gen risiko.DicesGenerator) risiko.BattleStrategy {
+			return risiko.NewRetreatAttackersStrategy(gen, thresholds)
+		}, nil
+	default:
+		return nil, usageErrorf("unknown %v strategy %q", side, spec)
+	}
+}
+
+func parseRetreatThresholds(params string) (risiko.RetreatThresholds, error) {
+	thresholds := risiko.RetreatThresholds{}
+	if params == "" {
+		return thresholds, fmt.Errorf("needs at least one threshold")
+	}
+	for _, param := range strings.Split(params, ",") {
+		key, value, _ := strings.Cut(param, "=")
+		var err error
+		switch key {
+		case "units":
+			thresholds.MinUnits, err = strconv.Atoi(value)
+		case "ratio":
+			thresholds.MinRatio, err = strconv.ParseFloat(value, 64)
+		case "odds":
+			thresholds.MinWinProbability, err = strconv.ParseFloat(value, 64)
+		default:
+			return thresholds, fmt.Errorf("unknown threshold %q, want units, ratio or odds", key)
+		}
+		if err != nil {
+			return thresholds, fmt.Errorf("threshold %q is not a number", param)
+		}
+	}
+	switch {
+	case thresholds.MinUnits < 0:
+		return thresholds, fmt.Errorf("units threshold %d is negative", thresholds.MinUnits)
+	case !(thresholds.MinRatio >= 0) || math.IsInf(thresholds.MinRatio, 1):
+		return thresholds, fmt.Errorf("ratio threshold %v is not a positive number", thresholds.MinRatio)
+	case !(thresholds.MinWinProbability >= 0 && thresholds.MinWinProbability <= 1):
+		return thresholds, fmt.Errorf("odds threshold %v is not between 0 and 1", thresholds.MinWinProbability)
+	}
+	return thresholds, nil
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ax6/risiko/pkg/risiko"
+)
+
+// Tables of battle outcomes for every pair of attacking and defending units
+func runSweep(ctx context.Context, args []string) error {
+	flags := newFlagSet("sweep", "Simulates, or solves exactly, every battle up to the given units and writes\nthe tables of the outcomes.")
+	nRuns := flags.Int("runs", 10000, "battles simulated for every pair of units")
+	unitsSweep := flags.Int("units", 20, "most attacking and defending units of the square sweep")
+	attackersRange := flags.String("attackers", "", "attacking units as FROM..TO or FROM..TO/STEP, e.g. 10..60/5. Defaults to the square sweep")
+	defendersRange := flags.String("defenders", "", "defending units as FROM..TO or FROM..TO/STEP. Defaults to the square sweep")
+	matchupsList := flags.String("matchups", "", "comma separated battles to sweep instead of a grid, e.g. 10v5,20v3")
+	rulesNames := flags.String("rules", "risiko", fmt.Sprintf("comma separated rules to play, any of %v. Tables are prefixed with the rules name when there's more than one", risiko.RulesPresetNames()))
+	attackerSpec := flags.String("attacker", "max", attackerStrategyHelp)
+	defenderSpec := flags.String("defender", "max", defenderStrategyHelp)
+	seed := flags.Uint64("seed", 0, "master seed of the simulation, 0 picks a random one")
+	exact := flags.Bool("exact", false, "solve battles exactly instead of simulating them, only with max strategies")
+	workers := flags.Int("workers", 0, "simulation workers, 0 uses every CPU")
+	outDir := flags.String("out", ".", "directory the tables are written to")
+	format := flags.String("format", "csv", "format of the results: csv tables, or a json or jsonl file with the run metadata")
+	report := flags.Bool("report", false, "also write report.html with the heatmaps and the run metadata, comparing the rules with the first ones when there's more than one")
+	plotFormats := flags.String("plot", "", "also draw the tables as heatmaps, comma separated png or svg")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	names, allRules, err := parseRules(*rulesNames)
+	if err != nil {
+		return err
+	}
+	attacker, err := parseStrategy(risiko.AttackerSide, *attackerSpec)
+	if err != nil {
+		return err
+	}
+	defender, err := parseStrategy(risiko.DefenderSide, *defenderSpec)
+	if err != nil {
+		return err
+	}
+	if *exact && (*attackerSpec != "max" || *defenderSpec != "max") {
+		return usageErrorf("-exact only solves max strategies")
+	}
+	if *nRuns < 1 || *unitsSweep < 1 || *workers < 0 {
+		return usageErrorf("-runs and -units must be positive and -workers cannot be negative")
+	}
+	plots, err := parsePlotFormats(*plotFormats)
+	if err != nil {
+		return err
+	}
+	if *format != "csv" && *format != "json" && *format != "jsonl" {
+		return usageErrorf("unknown format %q, want csv, json or jsonl", *format)
+	}
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		return err
+	}
+	if *matchupsList != "" && (*attackersRange != "" || *defendersRange != "") {
+		return usageErrorf("-matchups cannot be combined with -attackers or -defenders")
+	}
+	if *seed == 0 {
+		*seed = rand.Uint64()
+	}
+
+	reportSweeps := []reportSweep{}
+	saveSweepReport := func() error {
+		if !*report {
+			return nil
+		}
+		return saveReport(filepath.Join(*outDir, "report.html"), reportSweeps...)
+	}
+	for i, name := range names {
+		rules := allRules[i]
+		matchups, err := parseMatchupsFlags(rules, *unitsSweep, *attackersRange, *defendersRange, *matchupsList)
+		if err != nil {
+			return err
+		}
+		prefix := ""
+		if len(names) > 1 {
+			prefix = name + "_"
+		}
+
+		metadata := risiko.SweepMetadata{
+			RulesName:        name,
+			Rules:            rules,
+			AttackerStrategy: *attackerSpec,
+			DefenderStrategy: *defenderSpec,
+			Exact:            *exact,
+			Timestamp:        time.Now().UTC(),
+			Version:          buildVersion(),
+		}
+
+		if *exact {
+			log.Printf("Solving %s battles....", name)
+			exactResult, err := risiko.SolveMatchups(rules, matchups)
+			if err != nil {
+				return fmt.Errorf("solving battles: %w", err)
+			}
+			cells := risiko.SweepCells(exactResult)
+			if err := savePlots(cells, name, plots, *outDir, prefix); err != nil {
+				return err
+			}
+			reportSweeps = append(reportSweeps, reportSweep{metadata: metadata, cells: cells})
+			if *format != "csv" {
+				if err := saveSweepJSON(exactResult, metadata, *format, *outDir, prefix); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := generateCSVTables(exactResult, matchups, *outDir, prefix); err != nil {
+				return err
+			}
+			continue
+		}
+
+		log.Printf("Starting %s simulation with seed %d....", name, *seed)
+
+		// Simulate and get the results
+		opts := []risiko.SimulateOption{risiko.WithRules(rules), risiko.WithMatchups(matchups), risiko.WithProgress(newProgressBar())}
+		if *workers > 0 {
+			opts = append(opts, risiko.WithWorkers(*workers))
+		}
+		simResult, err := risiko.SimulateSeeded(ctx, *seed, *nRuns, *unitsSweep, attacker, defender, opts...)
+		fmt.Fprintln(os.Stderr)
+		interrupted := errors.Is(err, risiko.ErrIncompleteSimulation)
+		if interrupted {
+			log.Printf("Simulation interrupted, saving partial results: %v", err)
+		} else if err != nil {
+			return fmt.Errorf("simulating battles: %w", err)
+		} else {
+			log.Println("Simulation finished successfully!")
+		}
+
+		metadata.Seed = *seed
+		metadata.RunsPerCell = *nRuns
+		cells := risiko.SweepCells(simResult)
+		if err := savePlots(cells, name, plots, *outDir, prefix); err != nil {
+			return err
+		}
+		reportSweeps = append(reportSweeps, reportSweep{metadata: metadata, cells: cells})
+		if *format != "csv" {
+			if err := saveSweepJSON(simResult, metadata, *format, *outDir, prefix); err != nil {
+				return err
+			}
+		} else {
+			// Generate and save the CSV tables
+			if err := generateCSVTables(simResult, matchups, *outDir, prefix); err != nil {
+				return err
+			}
+			if err := generateErrorCSVTables(simResult, matchups, *outDir, prefix); err != nil {
+				return err
+			}
+		}
+		if interrupted {
+			// Report the rules swept so far
+			if reportErr := saveSweepReport(); reportErr != nil {
+				return reportErr
+			}
+			return err
+		}
+	}
+	return saveSweepReport()
+}
+
+// Parses comma separated rules presets, returning their names alongside them
+func parseRules(rulesNames string) ([]string, []risiko.Rules, error) {
+	names := strings.Split(rulesNames, ",")
+	allRules := []risiko.Rules{}
+	for _, name := range names {
+		rules, err := risiko.RulesPreset(name)
+		if err != nil {
+			return nil, nil, &usageError{err: err}
+		}
+		allRules = append(allRules, rules)
+	}
+	return names, allRules, nil
+}
+
+// Writes the sweep to sweep.json or sweep.jsonl, with the given format
+func saveSweepJSON[T risiko.Outcome](sweep map[int]map[int]T, metadata risiko.SweepMetadata, format string, dir string, prefix string) error {
+	filename := filepath.Join(dir, prefix+"sweep."+format)
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if format == "jsonl" {
+		err = risiko.WriteSweepJSONLines(file, metadata, sweep)
+	} else {
+		err = risiko.WriteSweepJSON(file, metadata, sweep)
+	}
+	if err != nil {
+		return fmt.Errorf("saving %s: %w", filename, err)
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	log.Printf("%s created successfully.", filename)
+	return nil
+}
+
+// Module version and commit of the binary, as far as the build knows
+func buildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	version := info.Main.Version
+	if version != "(devel)" {
+		return version
+	}
+	// Built from a checkout, the version doesn't say which commit
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			version += " " + setting.Value
+		}
+	}
+	return version
+}
+
+func saveCSV(filename string, header []string, data [][]string) error {
+	// Create or open the CSV file
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// Create CSV writer
+	writer := csv.NewWriter(file)
+
+	// Write the header
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	// Write data
+	for _, record := range data {
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	// Flush the writer
+	writer.Flush()
+
+	// Check for errors while writing
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Header of the tables, the attacker units of each column
+func tableHeader(attackers []int) []string {
+	header := []string{"nUnits"}
+	for _, nAttackers := range attackers {
+		header = append(header, strconv.Itoa(nAttackers))
+	}
+	return header
+}
+
+// Writes the tables with a row per defender units and a column per attacker
+// units of the matchups. Cells of matchups that weren't played are empty.
+func generateCSVTables[T risiko.Outcome](simResult map[int]map[int]T, matchups risiko.Matchups, dir string, prefix string) error {
+	// Initialize slices to store the data for the two tables
+	var victoryTable [][]string
+	var attackersLeftTable [][]string
+	var expectedAttackersLeftTable [][]string
+
+	header := tableHeader(matchups.Attackers())
+
+	// Iterate over the simulation results to calculate percentages
+	for _, nDefenders := range matchups.Defenders() {
+		victoryRow := []string{strconv.Itoa(nDefenders)}               // First column: nDefenderUnits
+		attackersLeftRow := []string{strconv.Itoa(nDefenders)}         // First column: nDefenderUnits
+		expectedAttackersLeftRow := []string{strconv.Itoa(nDefenders)} // First column: nDefenderUnits
+
+		// Iterate over attacker units (columns)
+		for _, nAttackers := range matchups.Attackers() {
+			result, ok := simResult[nAttackers][nDefenders]
+			if !ok {
+				victoryRow = append(victoryRow, "")
+				attackersLeftRow = append(attackersLeftRow, "")
+				expectedAttackersLeftRow = append(expectedAttackersLeftRow, "")
+				continue
+			}
+
+			// Calculate the victory percentage for the attacker
+			victoryPercentage := result.WinProbability()
+			victoryRow = append(victoryRow, fmt.Sprintf("%.6f", victoryPercentage))
+
+			// Calculate units left when won
+			attackersLeftCount := result.AttackerUnitsLeftOnWin()
+			attackersLeftRow = append(attackersLeftRow, fmt.Sprintf("%.6f", attackersLeftCount))
+
+			// Calculate the percentage of expected attackers left
+			expectedAttackersLeftPercentage := result.ExpectedAttackerUnitsLeft() / float64(nAttackers)
+			expectedAttackersLeftRow = append(expectedAttackersLeftRow, fmt.Sprintf("%.6f", expectedAttackersLeftPercentage))
+		}
+
+		// Add the rows to the tables
+		victoryTable = append(victoryTable, victoryRow)
+		attackersLeftTable = append(attackersLeftTable, attackersLeftRow)
+		expectedAttackersLeftTable = append(expectedAttackersLeftTable, expectedAttackersLeftRow)
+	}
+
+	if err := saveCSV(filepath.Join(dir, prefix+"victory_percentage.csv"), header, victoryTable); err != nil {
+		return fmt.Errorf("saving victory table: %w", err)
+	}
+	if err := saveCSV(filepath.Join(dir, prefix+"attackers_left.csv"), header, attackersLeftTable); err != nil {
+		return fmt.Errorf("saving attackers left table: %w", err)
+	}
+	if err := saveCSV(filepath.Join(dir, prefix+"expected_attackers_left_percentage.csv"), header, expectedAttackersLeftTable); err != nil {
+		return fmt.Errorf("saving attackers left table: %w", err)
+	}
+
+	log.Println("CSV files created successfully.")
+	return nil
+}
+
+// Writes the sampling error of each simulated cell: the 95% Wilson interval of
+// the victory percentage and the standard error of the expected attackers left
+func generateErrorCSVTables(simResult risiko.SimulationSweep, matchups risiko.Matchups, dir string, prefix string) error {
+	var victoryLowTable [][]string
+	var victoryHighTable [][]string
+	var expectedAttackersLeftErrTable [][]string
+
+	header := tableHeader(matchups.Attackers())
+
+	for _, nDefenders := range matchups.Defenders() {
+		victoryLowRow := []string{strconv.Itoa(nDefenders)}
+		victoryHighRow := []string{strconv.Itoa(nDefenders)}
+		expectedAttackersLeftErrRow := []string{strconv.Itoa(nDefenders)}
+
+		for _, nAttackers := range matchups.Attackers() {
+			result, ok := simResult[nAttackers][nDefenders]
+			if !ok {
+				victoryLowRow = append(victoryLowRow, "")
+				victoryHighRow = append(victoryHighRow, "")
+				expectedAttackersLeftErrRow = append(expectedAttackersLeftErrRow, "")
+				continue
+			}
+
+			low, high, err := result.WinRateWilson(0.95)
+			if err != nil {
+				return fmt.Errorf("computing confidence interval: %w", err)
+			}
+			victoryLowRow = append(victoryLowRow, fmt.Sprintf("%.6f", low))
+			victoryHighRow = append(victoryHighRow, fmt.Sprintf("%.6f", high))
+
+			// Same scale as the expected attackers left percentage
+			stdErr := result.ExpectedAttackerUnitsLeftStdErr() / float64(nAttackers)
+			expectedAttackersLeftErrRow = append(expectedAttackersLeftErrRow, fmt.Sprintf("%.6f", stdErr))
+		}
+
+		victoryLowTable = append(victoryLowTable, victoryLowRow)
+		victoryHighTable = append(victoryHighTable, victoryHighRow)
+		expectedAttackersLeftErrTable = append(expectedAttackersLeftErrTable, expectedAttackersLeftErrRow)
+	}
+
+	if err := saveCSV(filepath.Join(dir, prefix+"victory_percentage_ci_low.csv"), header, victoryLowTable); err != nil {
+		return fmt.Errorf("saving victory interval table: %w", err)
+	}
+	if err := saveCSV(filepath.Join(dir, prefix+"victory_percentage_ci_high.csv"), header, victoryHighTable); err != nil {
+		return fmt.Errorf("saving victory interval table: %w", err)
+	}
+	if err := saveCSV(filepath.Join(dir, prefix+"expected_attackers_left_percentage_stderr.csv"), header, expectedAttackersLeftErrTable); err != nil {
+		return fmt.Errorf("saving attackers left error table: %w", err)
+	}
+
+	log.Println("Error CSV files created successfully.")
+	return nil
+}
+
+// Returns a progress callback drawing a progress bar on stderr at most every
+// 100ms
+func newProgressBar() func(risiko.Progress) {
+	const width = 40
+	var lastDraw time.Time
+	return func(p risiko.Progress) {
+		if time.Since(lastDraw) < 100*time.Millisecond && p.CellsDone < p.CellsTotal {
+			return
+		}
+		lastDraw = time.Now()
+		filled := 0
+		if p.CellsTotal > 0 {
+			filled = width * p.CellsDone / p.CellsTotal
+		}
+		bar := strings.Repeat("#", filled) + strings.Repeat(".", width-filled)
+		fmt.Fprintf(os.Stderr, "\r[%s] %d/%d cells, %d battles, ETA %v   ", bar, p.CellsDone, p.CellsTotal, p.BattlesRun, p.ETA.Round(time.Second))
+	}
+}